@@ -0,0 +1,144 @@
+package fbmessenger
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	graphURL   = "https://graph.facebook.com"
+	apiVersion = "v2.6"
+)
+
+/*
+apiURLBuilder builds the URLs of the Graph API endpoints used by Client. The query string,
+including the access token, is encoded with net/url, since access tokens and user supplied
+values may contain characters that are not safe in a URL.
+*/
+type apiURLBuilder struct {
+	base        string
+	version     string
+	accessToken string
+}
+
+// newAPIURLBuilder creates an apiURLBuilder for the Graph API, or for base when it is not
+// empty. The version is left out of the URLs when base is given.
+func newAPIURLBuilder(base, accessToken string) *apiURLBuilder {
+	if base == "" {
+		return &apiURLBuilder{base: graphURL, version: apiVersion, accessToken: accessToken}
+	}
+
+	return &apiURLBuilder{base: base, accessToken: accessToken}
+}
+
+// MessagesURL is the URL of the Send API.
+func (b *apiURLBuilder) MessagesURL() string {
+	return b.url("/me/messages", nil)
+}
+
+// ProfileURL is the URL of the profile of a user, with the fields to get as a comma
+// separated list.
+func (b *apiURLBuilder) ProfileURL(userId string, fields string) string {
+	return b.url("/"+url.PathEscape(userId), url.Values{"fields": {fields}})
+}
+
+// IdsForAppsURL is the URL of the app-scoped ids of a user, from their page-scoped id.
+func (b *apiURLBuilder) IdsForAppsURL(psid string) string {
+	return b.url("/"+url.PathEscape(psid)+"/ids_for_apps", nil)
+}
+
+// IdsForPagesURL is the URL of the page-scoped id of a user for a page, from their
+// app-scoped id.
+func (b *apiURLBuilder) IdsForPagesURL(asid, pageId string) string {
+	return b.url("/"+url.PathEscape(asid)+"/ids_for_pages", url.Values{"page": {pageId}})
+}
+
+// MessengerProfileURL is the URL of the messenger profile of the page, with the fields to
+// get as a comma separated list. The fields may be empty when setting or deleting properties.
+func (b *apiURLBuilder) MessengerProfileURL(fields string) string {
+	if fields == "" {
+		return b.url("/me/messenger_profile", nil)
+	}
+
+	return b.url("/me/messenger_profile", url.Values{"fields": {fields}})
+}
+
+// MessagingFeatureReviewURL is the URL of the review status of the features of the page.
+func (b *apiURLBuilder) MessagingFeatureReviewURL() string {
+	return b.url("/me/messaging_feature_review", nil)
+}
+
+// ThreadControlURL is the URL of one of the handover protocol actions, such as
+// "pass_thread_control".
+func (b *apiURLBuilder) ThreadControlURL(action string) string {
+	return b.url("/me/"+action, nil)
+}
+
+// ThreadOwnerURL is the URL of the app controlling the conversation with a user.
+func (b *apiURLBuilder) ThreadOwnerURL(userId string) string {
+	return b.url("/me/thread_owner", url.Values{"recipient": {userId}})
+}
+
+// MessageCreativesURL is the URL that message creatives are POSTed to.
+func (b *apiURLBuilder) MessageCreativesURL() string {
+	return b.url("/me/message_creatives", nil)
+}
+
+// BroadcastMessagesURL is the URL that broadcasts are POSTed to.
+func (b *apiURLBuilder) BroadcastMessagesURL() string {
+	return b.url("/me/broadcast_messages", nil)
+}
+
+// BroadcastURL is the URL of a broadcast, with the fields to get as a comma separated list.
+// The fields may be empty when cancelling the broadcast.
+func (b *apiURLBuilder) BroadcastURL(broadcastId int64, fields string) string {
+	if fields == "" {
+		return b.url(fmt.Sprintf("/%v", broadcastId), nil)
+	}
+
+	return b.url(fmt.Sprintf("/%v", broadcastId), url.Values{"fields": {fields}})
+}
+
+// BroadcastInsightsURL is the URL of the insights of a broadcast, with the metrics to get as
+// a comma separated list.
+func (b *apiURLBuilder) BroadcastInsightsURL(broadcastId int64, metrics string) string {
+	return b.url(fmt.Sprintf("/%v/insights/%v", broadcastId, metrics), nil)
+}
+
+// DebugTokenURL is the URL of the details of an access token.
+func (b *apiURLBuilder) DebugTokenURL(inputToken string) string {
+	return b.url("/debug_token", url.Values{"input_token": {inputToken}})
+}
+
+// OAuthAccessTokenURL is the URL for exchanging a short-lived user access token for a
+// long-lived one. It is authenticated with the app secret, so is built with no access token.
+func (b *apiURLBuilder) OAuthAccessTokenURL(appId, appSecret, shortLivedToken string) string {
+	return b.url("/oauth/access_token", url.Values{
+		"grant_type":        {"fb_exchange_token"},
+		"client_id":         {appId},
+		"client_secret":     {appSecret},
+		"fb_exchange_token": {shortLivedToken},
+	})
+}
+
+// BatchURL is the URL that batches of requests are POSTed to.
+func (b *apiURLBuilder) BatchURL() string {
+	return b.url("/", nil)
+}
+
+func (b *apiURLBuilder) url(path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	if b.accessToken != "" {
+		query.Set("access_token", b.accessToken)
+	}
+
+	u := b.base
+	if b.version != "" {
+		u += "/" + b.version
+	}
+
+	return u + path + "?" + query.Encode()
+}
@@ -6,38 +6,56 @@ type MessageEntryHandler func(cb *MessagingEntry) error
 /*
 CallbackDispatcher routes each MessagingEntry included in a callback to an appropriate
 handler for the type of entry. Note that due to webhook batching, a handler may be called
-more than once per callback.
+more than once per callback, possibly for entries from different pages. Use
+MessagingEntry.PageID to tell which page an entry belongs to.
 */
 type CallbackDispatcher struct {
 	MessageHandler        MessageEntryHandler
 	DeliveryHandler       MessageEntryHandler
+	ReadHandler           MessageEntryHandler
 	PostbackHandler       MessageEntryHandler
 	AuthenticationHandler MessageEntryHandler
+	ReactionHandler       MessageEntryHandler
+	MessageDeleteHandler  MessageEntryHandler
+	StoryMentionHandler   MessageEntryHandler
 }
 
 /*
 Dispatch routes each MessagingEntry included in the callback to an appropriate
-handler for the type of entry.
+handler for the type of entry. Instagram story mentions are messages, so they are
+routed to the MessageHandler unless a StoryMentionHandler is set.
 */
 func (dispatcher *CallbackDispatcher) Dispatch(cb *Callback) error {
-	for _, entry := range cb.Entries {
-		for _, messagingEntry := range entry.Messaging {
-			if messagingEntry.Message != nil {
-				if dispatcher.MessageHandler != nil {
-					dispatcher.MessageHandler(messagingEntry)
-				}
-			} else if messagingEntry.Delivery != nil {
-				if dispatcher.DeliveryHandler != nil {
-					dispatcher.DeliveryHandler(messagingEntry)
-				}
-			} else if messagingEntry.Postback != nil {
-				if dispatcher.PostbackHandler != nil {
-					dispatcher.PostbackHandler(messagingEntry)
-				}
-			} else if messagingEntry.OptIn != nil {
-				if dispatcher.AuthenticationHandler != nil {
-					dispatcher.AuthenticationHandler(messagingEntry)
-				}
+	for _, messagingEntry := range cb.FlattenMessaging() {
+		if messagingEntry.Message != nil && messagingEntry.Message.IsStoryMention() && dispatcher.StoryMentionHandler != nil {
+			dispatcher.StoryMentionHandler(messagingEntry)
+		} else if messagingEntry.Message != nil {
+			if dispatcher.MessageHandler != nil {
+				dispatcher.MessageHandler(messagingEntry)
+			}
+		} else if messagingEntry.Delivery != nil {
+			if dispatcher.DeliveryHandler != nil {
+				dispatcher.DeliveryHandler(messagingEntry)
+			}
+		} else if messagingEntry.Read != nil {
+			if dispatcher.ReadHandler != nil {
+				dispatcher.ReadHandler(messagingEntry)
+			}
+		} else if messagingEntry.Postback != nil {
+			if dispatcher.PostbackHandler != nil {
+				dispatcher.PostbackHandler(messagingEntry)
+			}
+		} else if messagingEntry.OptIn != nil {
+			if dispatcher.AuthenticationHandler != nil {
+				dispatcher.AuthenticationHandler(messagingEntry)
+			}
+		} else if messagingEntry.Reaction != nil {
+			if dispatcher.ReactionHandler != nil {
+				dispatcher.ReactionHandler(messagingEntry)
+			}
+		} else if messagingEntry.MessageDelete != nil {
+			if dispatcher.MessageDeleteHandler != nil {
+				dispatcher.MessageDeleteHandler(messagingEntry)
 			}
 		}
 	}
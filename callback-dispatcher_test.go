@@ -5,14 +5,20 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"encoding/json"
 )
 
 var _ = Describe("MessageEntryHandlerDispatcher", func() {
 	var (
 		messageHandlerCalls        int
 		deliveryHandlerCalls       int
+		readHandlerCalls           int
 		postbackHandlerCalls       int
 		authenticationHandlerCalls int
+		reactionHandlerCalls       int
+		messageDeleteHandlerCalls  int
+		storyMentionHandlerCalls   int
 	)
 
 	messageHandler := func(entry *MessagingEntry) error {
@@ -25,6 +31,11 @@ var _ = Describe("MessageEntryHandlerDispatcher", func() {
 		return nil
 	}
 
+	readHandler := func(entry *MessagingEntry) error {
+		readHandlerCalls++
+		return nil
+	}
+
 	postbackHandler := func(entry *MessagingEntry) error {
 		postbackHandlerCalls++
 		return nil
@@ -35,11 +46,30 @@ var _ = Describe("MessageEntryHandlerDispatcher", func() {
 		return nil
 	}
 
+	reactionHandler := func(entry *MessagingEntry) error {
+		reactionHandlerCalls++
+		return nil
+	}
+
+	messageDeleteHandler := func(entry *MessagingEntry) error {
+		messageDeleteHandlerCalls++
+		return nil
+	}
+
+	storyMentionHandler := func(entry *MessagingEntry) error {
+		storyMentionHandlerCalls++
+		return nil
+	}
+
 	BeforeEach(func() {
 		messageHandlerCalls = 0
 		deliveryHandlerCalls = 0
+		readHandlerCalls = 0
 		postbackHandlerCalls = 0
 		authenticationHandlerCalls = 0
+		reactionHandlerCalls = 0
+		messageDeleteHandlerCalls = 0
+		storyMentionHandlerCalls = 0
 	})
 
 	It("should dispatch message callbacks to the message handler", func() {
@@ -62,6 +92,16 @@ var _ = Describe("MessageEntryHandlerDispatcher", func() {
 		Expect(deliveryHandlerCalls).To(Equal(1))
 	})
 
+	It("should dispatch read callbacks to the read handler", func() {
+		dispatcher := &CallbackDispatcher{
+			ReadHandler: readHandler,
+		}
+
+		dispatcher.Dispatch(createReadCallback())
+
+		Expect(readHandlerCalls).To(Equal(1))
+	})
+
 	It("should dispatch postback callbacks to the postback handler", func() {
 		dispatcher := &CallbackDispatcher{
 			PostbackHandler: postbackHandler,
@@ -82,18 +122,93 @@ var _ = Describe("MessageEntryHandlerDispatcher", func() {
 		Expect(authenticationHandlerCalls).To(Equal(1))
 	})
 
+	It("should dispatch reaction callbacks to the reaction handler", func() {
+		dispatcher := &CallbackDispatcher{
+			ReactionHandler: reactionHandler,
+		}
+
+		dispatcher.Dispatch(createReactionCallback())
+
+		Expect(reactionHandlerCalls).To(Equal(1))
+	})
+
+	It("should dispatch message delete callbacks to the message delete handler", func() {
+		dispatcher := &CallbackDispatcher{
+			MessageDeleteHandler: messageDeleteHandler,
+		}
+
+		dispatcher.Dispatch(createMessageDeleteCallback())
+
+		Expect(messageDeleteHandlerCalls).To(Equal(1))
+	})
+
+	It("should dispatch story mention callbacks to the story mention handler", func() {
+		dispatcher := &CallbackDispatcher{
+			MessageHandler:      messageHandler,
+			StoryMentionHandler: storyMentionHandler,
+		}
+
+		dispatcher.Dispatch(createStoryMentionCallback())
+
+		Expect(storyMentionHandlerCalls).To(Equal(1))
+		Expect(messageHandlerCalls).To(Equal(0))
+	})
+
+	It("should dispatch story mention callbacks to the message handler when there is no story mention handler", func() {
+		dispatcher := &CallbackDispatcher{
+			MessageHandler: messageHandler,
+		}
+
+		dispatcher.Dispatch(createStoryMentionCallback())
+
+		Expect(messageHandlerCalls).To(Equal(1))
+	})
+
+	It("should dispatch entries with the page id of their entry", func() {
+		var pageIds []string
+		dispatcher := &CallbackDispatcher{
+			MessageHandler: func(entry *MessagingEntry) error {
+				pageIds = append(pageIds, entry.PageID())
+				return nil
+			},
+		}
+
+		cb := createMessageCallback()
+		other := createMessageCallback().Entries[0]
+		other.PageId = "321"
+		cb.Entries = append(cb.Entries, other)
+
+		callbackJSON, err := json.Marshal(cb)
+		Expect(err).To(BeNil())
+
+		var decoded Callback
+		Expect(json.Unmarshal(callbackJSON, &decoded)).To(Succeed())
+
+		dispatcher.Dispatch(&decoded)
+
+		Expect(pageIds).To(Equal([]string{"123", "321"}))
+	})
+
 	It("should not dispatch callbacks when there is no registered handler", func() {
 		dispatcher := &CallbackDispatcher{}
 
 		dispatcher.Dispatch(createMessageCallback())
 		dispatcher.Dispatch(createDeliveryCallback())
+		dispatcher.Dispatch(createReadCallback())
 		dispatcher.Dispatch(createPostbackCallback())
 		dispatcher.Dispatch(createAuthenticationCallback())
+		dispatcher.Dispatch(createReactionCallback())
+		dispatcher.Dispatch(createMessageDeleteCallback())
+		dispatcher.Dispatch(createStoryMentionCallback())
 
 		Expect(messageHandlerCalls).To(Equal(0))
 		Expect(deliveryHandlerCalls).To(Equal(0))
+		Expect(readHandlerCalls).To(Equal(0))
 		Expect(postbackHandlerCalls).To(Equal(0))
 		Expect(authenticationHandlerCalls).To(Equal(0))
+		Expect(reactionHandlerCalls).To(Equal(0))
+		Expect(messageDeleteHandlerCalls).To(Equal(0))
+		Expect(storyMentionHandlerCalls).To(Equal(0))
 	})
 })
 
@@ -134,6 +249,24 @@ func createDeliveryCallback() *Callback {
 	return cb
 }
 
+func createReadCallback() *Callback {
+	cb := createCallback()
+
+	cb.Entries[0].Messaging = []*MessagingEntry{
+		&MessagingEntry{
+			Sender:    Principal{Id: "456"},
+			Recipient: Principal{Id: "765"},
+			Timestamp: 876,
+			Read: &Read{
+				Watermark: 234,
+				Sequence:  88,
+			},
+		},
+	}
+
+	return cb
+}
+
 func createPostbackCallback() *Callback {
 	cb := createCallback()
 
@@ -168,6 +301,64 @@ func createAuthenticationCallback() *Callback {
 	return cb
 }
 
+func createReactionCallback() *Callback {
+	cb := createCallback()
+
+	cb.Entries[0].Messaging = []*MessagingEntry{
+		&MessagingEntry{
+			Sender:    Principal{Id: "456"},
+			Recipient: Principal{Id: "765"},
+			Timestamp: 876,
+			Reaction: &Reaction{
+				Action:       ReactionActionReact,
+				ReactionType: ReactionLove,
+				MessageId:    "mid.3345",
+			},
+		},
+	}
+
+	return cb
+}
+
+func createMessageDeleteCallback() *Callback {
+	cb := createCallback()
+
+	cb.Entries[0].Messaging = []*MessagingEntry{
+		&MessagingEntry{
+			Sender:    Principal{Id: "456"},
+			Recipient: Principal{Id: "765"},
+			Timestamp: 876,
+			MessageDelete: &MessageDelete{
+				MessageId: "mid.3345",
+			},
+		},
+	}
+
+	return cb
+}
+
+func createStoryMentionCallback() *Callback {
+	cb := createCallback()
+	cb.Object = "instagram"
+
+	cb.Entries[0].Messaging = []*MessagingEntry{
+		&MessagingEntry{
+			Sender:    Principal{Id: "456"},
+			Recipient: Principal{Id: "765"},
+			Timestamp: 876,
+			Message: &CallbackMessage{
+				MessageId: "mid.3345",
+				StoryMention: &StoryMention{
+					Link: "https://lookaside.fbsbx.com/ig_messaging_cdn/?asset_id=3345",
+					Id:   "3345",
+				},
+			},
+		},
+	}
+
+	return cb
+}
+
 func createCallback() *Callback {
 	return &Callback{
 		Object: "page",
@@ -0,0 +1,144 @@
+package fbmessenger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/*
+WithDebug writes each request the Client makes, and the response, to w, which is useful
+during development for seeing exactly what is sent to and returned from Facebook. JSON bodies
+are indented, and access tokens and app secrets in the URL and in JSON bodies are replaced
+with [REDACTED]. A line is written to w when the option is applied, so that it is clear debug
+mode is enabled. A nil w turns debug mode off.
+*/
+func WithDebug(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.debug = w
+		if w != nil {
+			io.WriteString(w, "fbmessenger: debug mode enabled\n")
+		}
+	}
+}
+
+// redactedParams are the query parameters that are never written by WithDebug. Keys of JSON
+// bodies that are one of these, or end in "_token", are never written either.
+var redactedParams = []string{"access_token", "client_secret", "fb_exchange_token", "input_token"}
+
+const redacted = "[REDACTED]"
+
+func (c *Client) writeDebugRequest(req *http.Request) {
+	var body []byte
+	if req.GetBody != nil {
+		if reader, err := req.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(reader)
+			reader.Close()
+		}
+	}
+
+	dump := &bytes.Buffer{}
+	fmt.Fprintf(dump, "→ REQUEST: %v %v\n", req.Method, redactURL(req.URL))
+	writeDebugBody(dump, body)
+
+	c.debug.Write(dump.Bytes())
+}
+
+func (c *Client) writeDebugResponse(resp *http.Response, body []byte) {
+	dump := &bytes.Buffer{}
+	fmt.Fprintf(dump, "← RESPONSE: %v\n", resp.Status)
+	writeDebugBody(dump, body)
+
+	c.debug.Write(dump.Bytes())
+}
+
+func writeDebugBody(dump *bytes.Buffer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	if err := json.Indent(dump, redactBody(body), "", "  "); err != nil {
+		fmt.Fprintf(dump, "(%v bytes that are not JSON)", len(body))
+	}
+
+	dump.WriteString("\n")
+}
+
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	for _, param := range redactedParams {
+		if _, ok := query[param]; ok {
+			query.Set(param, redacted)
+		}
+	}
+
+	redactedURL := *u
+	redactedURL.RawQuery = strings.Replace(query.Encode(), url.QueryEscape(redacted), redacted, -1)
+
+	return redactedURL.String()
+}
+
+// redactBody returns a JSON body with the values of sensitive keys replaced with [REDACTED].
+// The body is returned unchanged, keeping the order of its keys, when it has none, or when it
+// is not JSON.
+func redactBody(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || !redactValue(v) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return redactedBody
+}
+
+// redactValue replaces the values of sensitive keys in the maps within v, and reports whether
+// any were replaced.
+func redactValue(v interface{}) bool {
+	replaced := false
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isRedactedKey(key) {
+				v[key] = redacted
+				replaced = true
+			} else if redactValue(value) {
+				replaced = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactValue(value) {
+				replaced = true
+			}
+		}
+	}
+
+	return replaced
+}
+
+func isRedactedKey(key string) bool {
+	if strings.HasSuffix(key, "_token") {
+		return true
+	}
+
+	for _, param := range redactedParams {
+		if key == param {
+			return true
+		}
+	}
+
+	return false
+}
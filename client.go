@@ -3,44 +3,85 @@ package fbmessenger
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang.org/x/net/context"
+	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const apiURL = "https://graph.facebook.com/v2.6"
-
 type httpDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
 /*
-Client is used to send messages and get user profiles. Use the empty value in most cases.
-The URL field can be overridden to allow for writing integration tests that use a different
-endpoint (not Facebook).
+Client is used to send messages and get user profiles. Use the empty value in most cases,
+or NewClient to set options such as an app access token. The URL field can be overridden
+to allow for writing integration tests that use a different endpoint (not Facebook).
 */
 type Client struct {
-	URL      string
-	httpDoer httpDoer
+	URL            string
+	httpDoer       httpDoer
+	appAccessToken string
+	appId          string
+	appSecret      string
+	debug          io.Writer
+}
+
+// ClientOption functions set optional configuration of a Client created with NewClient.
+type ClientOption func(c *Client)
+
+// NewClient creates a Client with the given options applied.
+func NewClient(options ...ClientOption) *Client {
+	c := &Client{}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// WithAppAccessToken sets the app access token, which is used instead of a page access
+// token by the methods calling app level endpoints, such as GetPSIDFromASID.
+func WithAppAccessToken(token string) ClientOption {
+	return func(c *Client) {
+		c.appAccessToken = token
+	}
+}
+
+// WithAppCredentials sets the app id and app secret, which are used by GetLongLivedToken.
+func WithAppCredentials(appId, appSecret string) ClientOption {
+	return func(c *Client) {
+		c.appId = appId
+		c.appSecret = appSecret
+	}
 }
 
 /*
-Send POSTs a request to and returns a response from the Send API. A response from
-Facebook indicating an error does not return an error. Be sure to check for errors
-in sending, and errors in the response from Facebook.
+Send POSTs a request to and returns a response from the Send API. Facebook sometimes
+reports errors with an HTTP 200 response, so an error in the response from Facebook is
+returned as an error too. It is a *SendError, which can be told apart from errors in sending.
+The response is still returned, with its Error set.
 
 	response, err := client.Send(request, "YOUR_PAGE_ACCESS_TOKEN")
-	if err != nil {
-		//Got an error. Request never got to Facebook.
-	} else if response.Error != nil {
+	var sendError *SendError
+	if errors.As(err, &sendError) {
 		//Request got to Facebook. Facebook returned an error.
+	} else if err != nil {
+		//Got an error. Request never got to Facebook.
 	} else {
 		//Hooray!
 	}
-
 */
 func (c *Client) Send(sendRequest *SendRequest, pageAccessToken string) (*SendResponse, error) {
 	return c.SendWithContext(context.Background(), sendRequest, pageAccessToken)
@@ -48,13 +89,43 @@ func (c *Client) Send(sendRequest *SendRequest, pageAccessToken string) (*SendRe
 
 // SendWithContext is like Send but allows you to timeout or cancel the request using context.Context.
 func (c *Client) SendWithContext(ctx context.Context, sendRequest *SendRequest, pageAccessToken string) (*SendResponse, error) {
+	return c.SendWith(ctx, sendRequest, WithAccessToken(pageAccessToken))
+}
+
+// SendOption functions set optional configuration of a single call to SendWith.
+type SendOption func(o *sendOptions)
+
+type sendOptions struct {
+	pageAccessToken string
+}
+
+// WithAccessToken sets the page access token a call to SendWith is made with. It allows one
+// Client to send for many pages when the tokens are only known at the time of the call.
+func WithAccessToken(token string) SendOption {
+	return func(o *sendOptions) {
+		o.pageAccessToken = token
+	}
+}
+
+// SendWith is like SendWithContext, but with the page access token given as an option.
+func (c *Client) SendWith(ctx context.Context, sendRequest *SendRequest, options ...SendOption) (*SendResponse, error) {
+	o := &sendOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	pageAccessToken := o.pageAccessToken
+	if pageAccessToken == "" {
+		return nil, fmt.Errorf("a page access token is required, set it with WithAccessToken")
+	}
+
 	var req *http.Request
 	var err error
 
 	if isDataMessage(sendRequest) {
 		req, err = c.newFormDataRequest(sendRequest, pageAccessToken)
 	} else {
-		req, err = c.newJSONRequest(sendRequest, pageAccessToken)
+		req, err = c.newJSONRequest("POST", c.apiURLs(pageAccessToken).MessagesURL(), sendRequest)
 	}
 
 	if err != nil {
@@ -63,10 +134,68 @@ func (c *Client) SendWithContext(ctx context.Context, sendRequest *SendRequest,
 
 	response := &SendResponse{}
 	err = c.doRequest(ctx, req, response)
+	if sendError, ok := err.(*SendError); ok {
+		response.CorrelationId = sendRequest.CorrelationId
+		response.Error = sendError
+		return response, sendError
+	} else if err != nil {
+		return nil, err
+	}
+
+	response.CorrelationId = sendRequest.CorrelationId
+
+	return response, nil
+}
+
+// SendSenderAction POSTs a sender action, such as turning the typing indicator on.
+func (c *Client) SendSenderAction(senderActionRequest *SenderActionRequest, pageAccessToken string) error {
+	return c.SendSenderActionWithContext(context.Background(), senderActionRequest, pageAccessToken)
+}
+
+// SendSenderActionWithContext is like SendSenderAction but allows you to timeout or cancel the request using context.Context.
+func (c *Client) SendSenderActionWithContext(ctx context.Context, senderActionRequest *SenderActionRequest, pageAccessToken string) error {
+	return c.doAction(ctx, "POST", c.apiURLs(pageAccessToken).MessagesURL(), senderActionRequest)
+}
+
+/*
+SendWithTyping is like Send but first turns the typing indicator on in the conversation and
+waits for delay, so the user sees the message being typed. The typing indicator is turned
+off once the message has been sent, and also when sending fails.
+
+	response, err := client.SendWithTyping(TextMessage("Hello").To("USER_ID"), 500*time.Millisecond, "YOUR_PAGE_ACCESS_TOKEN")
+*/
+func (c *Client) SendWithTyping(sendRequest *SendRequest, delay time.Duration, pageAccessToken string) (*SendResponse, error) {
+	return c.SendWithTypingWithContext(context.Background(), sendRequest, delay, pageAccessToken)
+}
+
+// SendWithTypingWithContext is like SendWithTyping but allows you to timeout or cancel the request using context.Context.
+func (c *Client) SendWithTypingWithContext(ctx context.Context, sendRequest *SendRequest, delay time.Duration, pageAccessToken string) (*SendResponse, error) {
+	err := c.SendSenderActionWithContext(ctx, &SenderActionRequest{Recipient: sendRequest.Recipient, SenderAction: SenderActionTypingOn}, pageAccessToken)
 	if err != nil {
 		return nil, err
 	}
 
+	typingOff := &SenderActionRequest{Recipient: sendRequest.Recipient, SenderAction: SenderActionTypingOff}
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		c.SendSenderActionWithContext(context.Background(), typingOff, pageAccessToken)
+		return nil, ctx.Err()
+	}
+
+	response, err := c.SendWithContext(ctx, sendRequest, pageAccessToken)
+	if err != nil {
+		// The context may be what failed the send, so typing is turned off without it.
+		c.SendSenderActionWithContext(context.Background(), typingOff, pageAccessToken)
+		return response, err
+	}
+
+	err = c.SendSenderActionWithContext(ctx, typingOff, pageAccessToken)
+	if err != nil {
+		return response, err
+	}
+
 	return response, nil
 }
 
@@ -80,13 +209,13 @@ func isDataMessage(sendRequest *SendRequest) bool {
 	return ok
 }
 
-func (c *Client) newJSONRequest(sendRequest *SendRequest, pageAccessToken string) (*http.Request, error) {
-	requestBytes, err := json.Marshal(sendRequest)
+func (c *Client) newJSONRequest(method, url string, body interface{}) (*http.Request, error) {
+	requestBytes, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.buildURL("/me/messages?access_token="+pageAccessToken), bytes.NewBuffer(requestBytes))
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(requestBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -112,10 +241,19 @@ func (c *Client) newFormDataRequest(sendRequest *SendRequest, pageAccessToken st
 		return nil, err
 	}
 
-	if sendRequest.NotificationType != "" {
-		err = w.WriteField("notification_type", sendRequest.NotificationType)
-		if err != nil {
-			return nil, err
+	optionalFields := []struct{ name, value string }{
+		{"messaging_type", sendRequest.MessagingType},
+		{"notification_type", sendRequest.NotificationType},
+		{"tag", sendRequest.Tag},
+		{"persona_id", sendRequest.PersonaId},
+	}
+
+	for _, field := range optionalFields {
+		if field.value != "" {
+			err = w.WriteField(field.name, field.value)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -135,7 +273,7 @@ func (c *Client) newFormDataRequest(sendRequest *SendRequest, pageAccessToken st
 
 	w.Close()
 
-	req, err := http.NewRequest("POST", c.buildURL("/me/messages?access_token="+pageAccessToken), &reqBuffer)
+	req, err := http.NewRequest("POST", c.apiURLs(pageAccessToken).MessagesURL(), &reqBuffer)
 	//req, err := http.NewRequest("POST", "http://httpbin.org/post", &reqBuffer)
 	if err != nil {
 		return nil, err
@@ -146,6 +284,107 @@ func (c *Client) newFormDataRequest(sendRequest *SendRequest, pageAccessToken st
 	return req, nil
 }
 
+/*
+SendFile POSTs a message to the user with a local file attached, uploading the file as
+form data. The file is streamed from disk rather than read into memory. When mimeType is
+empty, it is detected from the extension of the file. The attachment type is "image",
+"audio" or "video" for files of those MIME types, and "file" otherwise.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages#file
+*/
+func (c *Client) SendFile(recipientId, filePath, mimeType, pageAccessToken string) (*SendResponse, error) {
+	return c.SendFileWithContext(context.Background(), recipientId, filePath, mimeType, pageAccessToken)
+}
+
+// SendFileWithContext is like SendFile but allows you to timeout or cancel the request using context.Context.
+func (c *Client) SendFileWithContext(ctx context.Context, recipientId, filePath, mimeType, pageAccessToken string) (*SendResponse, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(filePath))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+	}
+
+	message := Message{
+		Attachment: &Attachment{
+			Type:    attachmentTypeForMIMEType(mimeType),
+			Payload: struct{}{},
+		},
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	go func() {
+		defer file.Close()
+
+		pw.CloseWithError(writeFileFormData(w, Recipient{Id: recipientId}, message, file, mimeType))
+	}()
+
+	req, err := http.NewRequest("POST", c.apiURLs(pageAccessToken).MessagesURL(), pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	response := &SendResponse{}
+	err = c.doRequest(ctx, req, response)
+	pr.Close()
+	if sendError, ok := err.(*SendError); ok {
+		response.Error = sendError
+		return response, sendError
+	} else if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func writeFileFormData(w *multipart.Writer, recipient Recipient, message Message, file *os.File, mimeType string) error {
+	err := writeFormField(w, "recipient", recipient)
+	if err != nil {
+		return err
+	}
+
+	err = writeFormField(w, "message", message)
+	if err != nil {
+		return err
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, "filedata", filepath.Base(file.Name())))
+	header.Set("Content-Type", mimeType)
+
+	fileWriter, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fileWriter, file)
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func attachmentTypeForMIMEType(mimeType string) string {
+	for _, attachmentType := range []string{"image", "audio", "video"} {
+		if strings.HasPrefix(mimeType, attachmentType+"/") {
+			return attachmentType
+		}
+	}
+
+	return "file"
+}
+
 func writeFormField(w *multipart.Writer, fieldName string, value interface{}) error {
 	valueBytes, err := json.Marshal(value)
 	if err != nil {
@@ -162,9 +401,21 @@ func (c *Client) GetUserProfile(userId, pageAccessToken string) (*UserProfile, e
 
 // GetUserProfileWithContext is like GetUserProfile but allows you to timeout or cancel the request using context.Context.
 func (c *Client) GetUserProfileWithContext(ctx context.Context, userId, pageAccessToken string) (*UserProfile, error) {
-	url := c.buildURL(fmt.Sprintf("/%v?fields=first_name,last_name,profile_pic,locale,timezone,gender&access_token=%v", userId, pageAccessToken))
+	return c.GetUserProfileFieldsWithContext(ctx, userId, pageAccessToken, userProfileFields...)
+}
+
+/*
+GetUserProfileFields GETs only the named fields of the user's profile, such as
+"payment_price_points" or "requested_user_info", which require additional permissions
+and are not requested by GetUserProfile.
+*/
+func (c *Client) GetUserProfileFields(userId, pageAccessToken string, fields ...string) (*UserProfile, error) {
+	return c.GetUserProfileFieldsWithContext(context.Background(), userId, pageAccessToken, fields...)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// GetUserProfileFieldsWithContext is like GetUserProfileFields but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetUserProfileFieldsWithContext(ctx context.Context, userId, pageAccessToken string, fields ...string) (*UserProfile, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).ProfileURL(userId, strings.Join(fields, ",")), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -178,39 +429,960 @@ func (c *Client) GetUserProfileWithContext(ctx context.Context, userId, pageAcce
 	return userProfile, nil
 }
 
-func (c *Client) buildURL(path string) string {
-	url := c.URL
-	if url == "" {
-		url = apiURL
+// userProfileFields are the fields requested by GetUserProfile.
+var userProfileFields = []string{"first_name", "last_name", "profile_pic", "locale", "timezone", "gender"}
+
+/*
+GetPageInfo GETs details of the page, such as its name. Pass the names of the fields to get,
+such as "picture" or "fan_count", or no fields to get the id, name and category.
+
+See https://developers.facebook.com/docs/graph-api/reference/page
+*/
+func (c *Client) GetPageInfo(pageAccessToken string, fields ...string) (*PageInfo, error) {
+	return c.GetPageInfoWithContext(context.Background(), pageAccessToken, fields...)
+}
+
+// GetPageInfoWithContext is like GetPageInfo but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetPageInfoWithContext(ctx context.Context, pageAccessToken string, fields ...string) (*PageInfo, error) {
+	if len(fields) == 0 {
+		fields = pageInfoFields
+	}
+
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).ProfileURL("me", strings.Join(fields, ",")), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &struct {
+		PageInfo
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.PageInfo, nil
+}
+
+// pageInfoFields are the fields requested by GetPageInfo when no fields are given.
+var pageInfoFields = []string{"id", "name", "category"}
+
+/*
+GetASID GETs the app-scoped id of a user from their page-scoped id, for matching the user
+across the apps of a business. When the user has used more than one app of the business,
+the first id returned by Facebook is used.
+
+See https://developers.facebook.com/docs/messenger-platform/identity/id-matching
+*/
+func (c *Client) GetASID(psid, pageAccessToken string) (string, error) {
+	return c.GetASIDWithContext(context.Background(), psid, pageAccessToken)
+}
+
+// GetASIDWithContext is like GetASID but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetASIDWithContext(ctx context.Context, psid, pageAccessToken string) (string, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).IdsForAppsURL(psid), nil)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := c.doIdsRequest(ctx, req)
+	if err != nil {
+		return "", err
 	}
 
-	return url + path
+	if id == "" {
+		return "", fmt.Errorf("facebook returned no app-scoped id for user %v", psid)
+	}
+
+	return id, nil
 }
 
-func (c *Client) doRequest(ctx context.Context, req *http.Request, responseStruct interface{}) error {
-	req.Cancel = ctx.Done()
+/*
+GetPSIDFromASID GETs the page-scoped id of a user for a page from their app-scoped id. The
+request is made with the app access token of the Client, which must be set with
+WithAppAccessToken.
 
-	doer := c.httpDoer
-	if doer == nil {
-		doer = &http.Client{}
+See https://developers.facebook.com/docs/messenger-platform/identity/id-matching
+*/
+func (c *Client) GetPSIDFromASID(asid, pageId string) (string, error) {
+	return c.GetPSIDFromASIDWithContext(context.Background(), asid, pageId)
+}
+
+// GetPSIDFromASIDWithContext is like GetPSIDFromASID but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetPSIDFromASIDWithContext(ctx context.Context, asid, pageId string) (string, error) {
+	if c.appAccessToken == "" {
+		return "", fmt.Errorf("an app access token is required, set it with WithAppAccessToken")
 	}
 
-	resp, err := doer.Do(req)
+	req, err := http.NewRequest("GET", c.apiURLs(c.appAccessToken).IdsForPagesURL(asid, pageId), nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	defer resp.Body.Close()
+	id, err := c.doIdsRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if id == "" {
+		return "", fmt.Errorf("facebook returned no page-scoped id for user %v on page %v", asid, pageId)
+	}
+
+	return id, nil
+}
+
+// doIdsRequest sends a request to one of the id matching endpoints and returns the first id
+// in the response, or an empty string when there are none.
+func (c *Client) doIdsRequest(ctx context.Context, req *http.Request) (string, error) {
+	response := &struct {
+		Data []*struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}{}
+	err := c.doRequest(ctx, req, response)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if len(response.Data) == 0 {
+		return "", nil
+	}
+
+	return response.Data[0].Id, nil
+}
+
+/*
+InspectToken GETs the details of an access token, such as whether it is valid and when it
+expires, for deciding when a token needs to be refreshed. The request is made with the app
+access token of the Client, which must be set with WithAppAccessToken.
+
+See https://developers.facebook.com/docs/facebook-login/guides/access-tokens/debugging
+*/
+func (c *Client) InspectToken(inputToken string) (*TokenInfo, error) {
+	return c.InspectTokenWithContext(context.Background(), inputToken)
+}
+
+// InspectTokenWithContext is like InspectToken but allows you to timeout or cancel the request using context.Context.
+func (c *Client) InspectTokenWithContext(ctx context.Context, inputToken string) (*TokenInfo, error) {
+	if c.appAccessToken == "" {
+		return nil, fmt.Errorf("an app access token is required, set it with WithAppAccessToken")
 	}
 
-	err = json.Unmarshal(body, responseStruct)
+	req, err := http.NewRequest("GET", c.apiURLs(c.appAccessToken).DebugTokenURL(inputToken), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	response := &struct {
+		Data *TokenInfo `json:"data"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Data == nil {
+		return nil, fmt.Errorf("facebook returned no details for the token")
+	}
+
+	return response.Data, nil
+}
+
+/*
+IsTokenValid checks that a page access token can still be used, by GETting the id of the
+page with it. False is returned with no error when Facebook rejects the token, and with an
+error when the check could not be made.
+*/
+func (c *Client) IsTokenValid(pageAccessToken string) (bool, error) {
+	return c.IsTokenValidWithContext(context.Background(), pageAccessToken)
+}
+
+// IsTokenValidWithContext is like IsTokenValid but allows you to timeout or cancel the request using context.Context.
+func (c *Client) IsTokenValidWithContext(ctx context.Context, pageAccessToken string) (bool, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).ProfileURL("me", "id"), nil)
+	if err != nil {
+		return false, err
+	}
+
+	response := &actionResponse{}
+	err = c.doRequest(ctx, req, response)
+	if errors.Is(err, ErrAccessToken) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+ExchangeForPageToken GETs the access token of a page using the access token of a user who
+manages it. When the user token is long-lived, as returned by GetLongLivedToken, the page
+token does not expire.
+
+See https://developers.facebook.com/docs/facebook-login/guides/access-tokens/get-long-lived
+*/
+func (c *Client) ExchangeForPageToken(userAccessToken, pageId string) (string, error) {
+	return c.ExchangeForPageTokenWithContext(context.Background(), userAccessToken, pageId)
+}
+
+// ExchangeForPageTokenWithContext is like ExchangeForPageToken but allows you to timeout or cancel the request using context.Context.
+func (c *Client) ExchangeForPageTokenWithContext(ctx context.Context, userAccessToken, pageId string) (string, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(userAccessToken).ProfileURL(pageId, "access_token"), nil)
+	if err != nil {
+		return "", err
+	}
+
+	response := &struct {
+		AccessToken string     `json:"access_token"`
+		Error       *SendError `json:"error"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return "", err
+	}
+
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("facebook returned no access token for page %v", pageId)
+	}
+
+	return response.AccessToken, nil
+}
+
+/*
+GetLongLivedToken exchanges a short-lived user access token for a long-lived one, which
+lasts about 60 days, and returns it with the time it expires. The request is made with the
+app id and secret of the Client, which must be set with WithAppCredentials.
+
+See https://developers.facebook.com/docs/facebook-login/guides/access-tokens/get-long-lived
+*/
+func (c *Client) GetLongLivedToken(shortLivedToken string) (string, time.Time, error) {
+	return c.GetLongLivedTokenWithContext(context.Background(), shortLivedToken)
+}
+
+// GetLongLivedTokenWithContext is like GetLongLivedToken but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetLongLivedTokenWithContext(ctx context.Context, shortLivedToken string) (string, time.Time, error) {
+	if c.appId == "" || c.appSecret == "" {
+		return "", time.Time{}, fmt.Errorf("an app id and secret are required, set them with WithAppCredentials")
+	}
+
+	req, err := http.NewRequest("GET", c.apiURLs("").OAuthAccessTokenURL(c.appId, c.appSecret, shortLivedToken), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	response := &struct {
+		AccessToken string     `json:"access_token"`
+		ExpiresIn   int64      `json:"expires_in"`
+		Error       *SendError `json:"error"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var expiresAt time.Time
+	if response.ExpiresIn != 0 {
+		expiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+
+	return response.AccessToken, expiresAt, nil
+}
+
+/*
+BatchGet makes several GET requests to the Graph API in one HTTP call, with the page access
+token set on the batch rather than on each request. The responses are returned in the order
+of the requests. A response is nil when Facebook did not complete its request, for example
+because the batch timed out.
+
+	responses, err := client.BatchGet([]*BatchGetRequest{
+		UserProfileBatchRequest("USER_ID_1"),
+		UserProfileBatchRequest("USER_ID_2"),
+	}, "YOUR_PAGE_ACCESS_TOKEN")
+
+See https://developers.facebook.com/docs/graph-api/batch-requests
+*/
+func (c *Client) BatchGet(requests []*BatchGetRequest, pageAccessToken string) ([]*BatchGetResponse, error) {
+	return c.BatchGetWithContext(context.Background(), requests, pageAccessToken)
+}
+
+// BatchGetWithContext is like BatchGet but allows you to timeout or cancel the request using context.Context.
+func (c *Client) BatchGetWithContext(ctx context.Context, requests []*BatchGetRequest, pageAccessToken string) ([]*BatchGetResponse, error) {
+	batch := make([]*batchRequest, len(requests))
+	for i, request := range requests {
+		batch[i] = &batchRequest{Method: "GET", RelativeURL: request.RelativeURL}
+	}
+
+	req, err := c.newJSONRequest("POST", c.apiURLs(pageAccessToken).BatchURL(), &struct {
+		Batch []*batchRequest `json:"batch"`
+	}{batch})
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*BatchGetResponse
+	err = c.doRequest(ctx, req, &responses)
+	if err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+type batchRequest struct {
+	Method      string `json:"method"`
+	RelativeURL string `json:"relative_url"`
+}
+
+/*
+GetMessengerProfile GETs the messenger profile properties of the page. Pass the names
+of the fields to get, such as "greeting" or "persistent_menu", or no fields to get all
+of the properties supported by MessengerProfileResponse.
+
+See https://developers.facebook.com/docs/messenger-platform/messenger-profile
+*/
+func (c *Client) GetMessengerProfile(pageAccessToken string, fields ...string) (*MessengerProfileResponse, error) {
+	return c.GetMessengerProfileWithContext(context.Background(), pageAccessToken, fields...)
+}
+
+// GetMessengerProfileWithContext is like GetMessengerProfile but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetMessengerProfileWithContext(ctx context.Context, pageAccessToken string, fields ...string) (*MessengerProfileResponse, error) {
+	if len(fields) == 0 {
+		fields = messengerProfileFields
+	}
+
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).MessengerProfileURL(strings.Join(fields, ",")), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &messengerProfileResponse{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &MessengerProfileResponse{}
+	if len(response.Data) > 0 {
+		data := response.Data[0]
+
+		if data.GetStarted != nil {
+			profile.GetStartedPayload = data.GetStarted.Payload
+		}
+
+		profile.GreetingTexts = data.Greeting
+		profile.PersistentMenus = data.PersistentMenu
+		profile.WhitelistedDomains = data.WhitelistedDomains
+		profile.AccountLinkingURL = data.AccountLinkingURL
+		profile.IceBreakers = data.IceBreakers
+		profile.HomeURL = data.HomeURL
+	}
+
+	return profile, nil
+}
+
+// DeleteMessengerProfileFields DELETEs the named messenger profile properties of the page.
+func (c *Client) DeleteMessengerProfileFields(fields []string, pageAccessToken string) error {
+	return c.DeleteMessengerProfileFieldsWithContext(context.Background(), fields, pageAccessToken)
+}
+
+// DeleteMessengerProfileFieldsWithContext is like DeleteMessengerProfileFields but allows you to timeout or cancel the request using context.Context.
+func (c *Client) DeleteMessengerProfileFieldsWithContext(ctx context.Context, fields []string, pageAccessToken string) error {
+	body := struct {
+		Fields []string `json:"fields"`
+	}{fields}
+
+	return c.doAction(ctx, "DELETE", c.apiURLs(pageAccessToken).MessengerProfileURL(""), body)
+}
+
+/*
+SetIceBreakers POSTs the ice breakers of the page, which are questions a user can tap to
+start a conversation. Any existing ice breakers are replaced. The ice breakers are
+validated before sending, and at most 4 are allowed.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messenger-profile-api/ice-breakers
+*/
+func (c *Client) SetIceBreakers(iceBreakers []*IceBreaker, pageAccessToken string) error {
+	return c.SetIceBreakersWithContext(context.Background(), iceBreakers, pageAccessToken)
+}
+
+// SetIceBreakersWithContext is like SetIceBreakers but allows you to timeout or cancel the request using context.Context.
+func (c *Client) SetIceBreakersWithContext(ctx context.Context, iceBreakers []*IceBreaker, pageAccessToken string) error {
+	err := validateIceBreakers(iceBreakers)
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		IceBreakers []*IceBreaker `json:"ice_breakers"`
+	}{iceBreakers}
+
+	return c.doAction(ctx, "POST", c.apiURLs(pageAccessToken).MessengerProfileURL(""), body)
+}
+
+// GetIceBreakers GETs the ice breakers of the page.
+func (c *Client) GetIceBreakers(pageAccessToken string) ([]*IceBreaker, error) {
+	return c.GetIceBreakersWithContext(context.Background(), pageAccessToken)
+}
+
+// GetIceBreakersWithContext is like GetIceBreakers but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetIceBreakersWithContext(ctx context.Context, pageAccessToken string) ([]*IceBreaker, error) {
+	profile, err := c.GetMessengerProfileWithContext(ctx, pageAccessToken, "ice_breakers")
+	if err != nil {
+		return nil, err
+	}
+
+	return profile.IceBreakers, nil
+}
+
+// DeleteIceBreakers DELETEs the ice breakers of the page.
+func (c *Client) DeleteIceBreakers(pageAccessToken string) error {
+	return c.DeleteIceBreakersWithContext(context.Background(), pageAccessToken)
+}
+
+// DeleteIceBreakersWithContext is like DeleteIceBreakers but allows you to timeout or cancel the request using context.Context.
+func (c *Client) DeleteIceBreakersWithContext(ctx context.Context, pageAccessToken string) error {
+	return c.DeleteMessengerProfileFieldsWithContext(ctx, []string{"ice_breakers"}, pageAccessToken)
+}
+
+/*
+SetHomeURL POSTs the home URL of the page, which opens in the Messenger webview for the
+chat extension of the page. The whitelisted domains of the page are read from its messenger
+profile first, and the home URL is validated against them before sending.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messenger-profile-api/home-url
+*/
+func (c *Client) SetHomeURL(homeURL *HomeURL, pageAccessToken string) error {
+	return c.SetHomeURLWithContext(context.Background(), homeURL, pageAccessToken)
+}
+
+// SetHomeURLWithContext is like SetHomeURL but allows you to timeout or cancel the request using context.Context.
+func (c *Client) SetHomeURLWithContext(ctx context.Context, homeURL *HomeURL, pageAccessToken string) error {
+	profile, err := c.GetMessengerProfileWithContext(ctx, pageAccessToken, "whitelisted_domains")
+	if err != nil {
+		return err
+	}
+
+	err = homeURL.Validate(profile.WhitelistedDomains...)
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		HomeURL *HomeURL `json:"home_url"`
+	}{homeURL}
+
+	return c.doAction(ctx, "POST", c.apiURLs(pageAccessToken).MessengerProfileURL(""), body)
+}
+
+// GetHomeURL GETs the home URL of the page. Nil is returned when no home URL is set.
+func (c *Client) GetHomeURL(pageAccessToken string) (*HomeURL, error) {
+	return c.GetHomeURLWithContext(context.Background(), pageAccessToken)
+}
+
+// GetHomeURLWithContext is like GetHomeURL but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetHomeURLWithContext(ctx context.Context, pageAccessToken string) (*HomeURL, error) {
+	profile, err := c.GetMessengerProfileWithContext(ctx, pageAccessToken, "home_url")
+	if err != nil {
+		return nil, err
+	}
+
+	return profile.HomeURL, nil
+}
+
+// DeleteHomeURL DELETEs the home URL of the page.
+func (c *Client) DeleteHomeURL(pageAccessToken string) error {
+	return c.DeleteHomeURLWithContext(context.Background(), pageAccessToken)
+}
+
+// DeleteHomeURLWithContext is like DeleteHomeURL but allows you to timeout or cancel the request using context.Context.
+func (c *Client) DeleteHomeURLWithContext(ctx context.Context, pageAccessToken string) error {
+	return c.DeleteMessengerProfileFieldsWithContext(ctx, []string{"home_url"}, pageAccessToken)
+}
+
+// messengerProfileFields are the fields requested by GetMessengerProfile when none are given.
+var messengerProfileFields = []string{"get_started", "greeting", "persistent_menu", "whitelisted_domains", "account_linking_url", "ice_breakers", "home_url"}
+
+type messengerProfileResponse struct {
+	Data []*messengerProfileData `json:"data"`
+}
+
+type messengerProfileData struct {
+	GetStarted *struct {
+		Payload string `json:"payload"`
+	} `json:"get_started"`
+	Greeting           []*Greeting       `json:"greeting"`
+	PersistentMenu     []*PersistentMenu `json:"persistent_menu"`
+	WhitelistedDomains []string          `json:"whitelisted_domains"`
+	AccountLinkingURL  string            `json:"account_linking_url"`
+	IceBreakers        []*IceBreaker     `json:"ice_breakers"`
+	HomeURL            *HomeURL          `json:"home_url"`
+}
+
+/*
+GetMessengerFeatures GETs the review status of each feature the page has submitted for
+review, such as payments. Use FeaturesMap to check the status of a particular feature.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messaging-feature-review-api
+*/
+func (c *Client) GetMessengerFeatures(pageAccessToken string) (MessengerFeatures, error) {
+	return c.GetMessengerFeaturesWithContext(context.Background(), pageAccessToken)
+}
+
+// GetMessengerFeaturesWithContext is like GetMessengerFeatures but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetMessengerFeaturesWithContext(ctx context.Context, pageAccessToken string) (MessengerFeatures, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).MessagingFeatureReviewURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &struct {
+		Data MessengerFeatures `json:"data"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+/*
+PassThreadControl passes control of the conversation with a user to another app, as part
+of the handover protocol. The metadata is optional and is delivered to the receiving app.
+
+See https://developers.facebook.com/docs/messenger-platform/handover-protocol/pass-thread-control
+*/
+func (c *Client) PassThreadControl(userId, targetAppId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.PassThreadControlWithContext(context.Background(), userId, targetAppId, metadata, pageAccessToken)
+}
+
+// PassThreadControlWithContext is like PassThreadControl but allows you to timeout or cancel the request using context.Context.
+func (c *Client) PassThreadControlWithContext(ctx context.Context, userId, targetAppId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.sendThreadControl(ctx, "pass_thread_control", userId, targetAppId, metadata, pageAccessToken)
+}
+
+/*
+TakeThreadControl takes control of the conversation with a user from the app currently
+controlling it. Only the primary receiver app may take thread control.
+
+See https://developers.facebook.com/docs/messenger-platform/handover-protocol/take-thread-control
+*/
+func (c *Client) TakeThreadControl(userId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.TakeThreadControlWithContext(context.Background(), userId, metadata, pageAccessToken)
+}
+
+// TakeThreadControlWithContext is like TakeThreadControl but allows you to timeout or cancel the request using context.Context.
+func (c *Client) TakeThreadControlWithContext(ctx context.Context, userId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.sendThreadControl(ctx, "take_thread_control", userId, "", metadata, pageAccessToken)
+}
+
+/*
+RequestThreadControl asks the primary receiver app to pass control of the conversation
+with a user to this app.
+
+See https://developers.facebook.com/docs/messenger-platform/handover-protocol/request-thread-control
+*/
+func (c *Client) RequestThreadControl(userId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.RequestThreadControlWithContext(context.Background(), userId, metadata, pageAccessToken)
+}
+
+// RequestThreadControlWithContext is like RequestThreadControl but allows you to timeout or cancel the request using context.Context.
+func (c *Client) RequestThreadControlWithContext(ctx context.Context, userId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	return c.sendThreadControl(ctx, "request_thread_control", userId, "", metadata, pageAccessToken)
+}
+
+/*
+GetThreadOwner GETs the app that currently controls the conversation with a user. Role is
+only set when Facebook includes it in the response.
+
+See https://developers.facebook.com/docs/messenger-platform/handover-protocol/get-thread-owner
+*/
+func (c *Client) GetThreadOwner(userId, pageAccessToken string) (*ThreadOwnerResponse, error) {
+	return c.GetThreadOwnerWithContext(context.Background(), userId, pageAccessToken)
+}
+
+// GetThreadOwnerWithContext is like GetThreadOwner but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetThreadOwnerWithContext(ctx context.Context, userId, pageAccessToken string) (*ThreadOwnerResponse, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).ThreadOwnerURL(userId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &struct {
+		Data []*struct {
+			ThreadOwner *ThreadOwnerResponse `json:"thread_owner"`
+		} `json:"data"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) == 0 || response.Data[0].ThreadOwner == nil {
+		return nil, fmt.Errorf("facebook returned no thread owner for user %v", userId)
+	}
+
+	return response.Data[0].ThreadOwner, nil
+}
+
+type threadControlRequest struct {
+	Recipient   Recipient `json:"recipient"`
+	TargetAppId string    `json:"target_app_id,omitempty"`
+	Metadata    string    `json:"metadata,omitempty"`
+}
+
+func (c *Client) sendThreadControl(ctx context.Context, action, userId, targetAppId string, metadata *HandoverMetadata, pageAccessToken string) error {
+	threadControl := &threadControlRequest{
+		Recipient:   Recipient{Id: userId},
+		TargetAppId: targetAppId,
+	}
+
+	if metadata != nil {
+		metadataBytes, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("error marshaling handover metadata: %v", err)
+		}
+
+		threadControl.Metadata = string(metadataBytes)
+	}
+
+	return c.doAction(ctx, "POST", c.apiURLs(pageAccessToken).ThreadControlURL(action), threadControl)
+}
+
+/*
+CreateMessageCreative POSTs messages to be stored by Facebook as a message creative, which
+can then be broadcast with Broadcast.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/broadcast-messages
+*/
+func (c *Client) CreateMessageCreative(messages []*Message, pageAccessToken string) (*MessageCreative, error) {
+	return c.CreateMessageCreativeWithContext(context.Background(), messages, pageAccessToken)
+}
+
+// CreateMessageCreativeWithContext is like CreateMessageCreative but allows you to timeout or cancel the request using context.Context.
+func (c *Client) CreateMessageCreativeWithContext(ctx context.Context, messages []*Message, pageAccessToken string) (*MessageCreative, error) {
+	req, err := c.newJSONRequest("POST", c.apiURLs(pageAccessToken).MessageCreativesURL(), &MessageCreativeRequest{Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &struct {
+		MessageCreative
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.MessageCreative, nil
+}
+
+/*
+Broadcast POSTs a broadcast of a message creative to the users subscribed to the page, and
+returns the id of the broadcast.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/broadcast-messages
+*/
+func (c *Client) Broadcast(broadcastRequest *BroadcastRequest, pageAccessToken string) (int64, error) {
+	return c.BroadcastWithContext(context.Background(), broadcastRequest, pageAccessToken)
+}
+
+// BroadcastWithContext is like Broadcast but allows you to timeout or cancel the request using context.Context.
+func (c *Client) BroadcastWithContext(ctx context.Context, broadcastRequest *BroadcastRequest, pageAccessToken string) (int64, error) {
+	req, err := c.newJSONRequest("POST", c.apiURLs(pageAccessToken).BroadcastMessagesURL(), broadcastRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	response := &struct {
+		BroadcastId int64      `json:"broadcast_id"`
+		Error       *SendError `json:"error"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.BroadcastId, nil
+}
+
+// ScheduleBroadcast is like Broadcast but schedules the broadcast of a message creative for
+// a time at least MinBroadcastScheduleDelay in the future, which is checked before sending.
+func (c *Client) ScheduleBroadcast(messageCreativeId int64, at time.Time, pageAccessToken string) (int64, error) {
+	return c.ScheduleBroadcastWithContext(context.Background(), messageCreativeId, at, pageAccessToken)
+}
+
+// ScheduleBroadcastWithContext is like ScheduleBroadcast but allows you to timeout or cancel the request using context.Context.
+func (c *Client) ScheduleBroadcastWithContext(ctx context.Context, messageCreativeId int64, at time.Time, pageAccessToken string) (int64, error) {
+	broadcastRequest := &BroadcastRequest{
+		MessageCreativeId: messageCreativeId,
+		ScheduledTime:     &at,
+	}
+
+	if err := broadcastRequest.Validate(); err != nil {
+		return 0, err
+	}
+
+	return c.BroadcastWithContext(ctx, broadcastRequest, pageAccessToken)
+}
+
+/*
+ABTestBroadcast broadcasts each variant of an A/B test, creating a message creative for it
+first, and returns the id of the broadcast of each variant by the name of the variant. The
+variants are checked before anything is sent. When a variant fails, the ids of the variants
+already broadcast are returned with the error. Compare the variants with GetABTestInsights.
+*/
+func (c *Client) ABTestBroadcast(variants []*ABTestBroadcast, pageAccessToken string) (map[string]int64, error) {
+	return c.ABTestBroadcastWithContext(context.Background(), variants, pageAccessToken)
+}
+
+// ABTestBroadcastWithContext is like ABTestBroadcast but allows you to timeout or cancel the request using context.Context.
+func (c *Client) ABTestBroadcastWithContext(ctx context.Context, variants []*ABTestBroadcast, pageAccessToken string) (map[string]int64, error) {
+	if err := validateABTest(variants); err != nil {
+		return nil, err
+	}
+
+	broadcastIds := map[string]int64{}
+	for _, variant := range variants {
+		creative, err := c.CreateMessageCreativeWithContext(ctx, variant.Messages, pageAccessToken)
+		if err != nil {
+			return broadcastIds, fmt.Errorf("variant %q: %w", variant.Variant, err)
+		}
+
+		broadcastId, err := c.BroadcastWithContext(ctx, &BroadcastRequest{
+			MessageCreativeId: creative.Id,
+			NotificationType:  variant.NotificationType,
+			CustomLabelId:     variant.CustomLabelId,
+		}, pageAccessToken)
+		if err != nil {
+			return broadcastIds, fmt.Errorf("variant %q: %w", variant.Variant, err)
+		}
+
+		broadcastIds[variant.Variant] = broadcastId
+	}
+
+	return broadcastIds, nil
+}
+
+// GetABTestInsights GETs the insights of the broadcast of each variant returned by
+// ABTestBroadcast, by the name of the variant.
+func (c *Client) GetABTestInsights(broadcastIds map[string]int64, pageAccessToken string) (map[string]*BroadcastInsights, error) {
+	return c.GetABTestInsightsWithContext(context.Background(), broadcastIds, pageAccessToken)
+}
+
+// GetABTestInsightsWithContext is like GetABTestInsights but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetABTestInsightsWithContext(ctx context.Context, broadcastIds map[string]int64, pageAccessToken string) (map[string]*BroadcastInsights, error) {
+	insights := map[string]*BroadcastInsights{}
+	for variant, broadcastId := range broadcastIds {
+		variantInsights, err := c.GetBroadcastInsightsWithContext(ctx, broadcastId, pageAccessToken)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", variant, err)
+		}
+
+		insights[variant] = variantInsights
+	}
+
+	return insights, nil
+}
+
+// CancelBroadcast cancels a scheduled broadcast that has not been sent yet.
+func (c *Client) CancelBroadcast(broadcastId int64, pageAccessToken string) error {
+	return c.CancelBroadcastWithContext(context.Background(), broadcastId, pageAccessToken)
+}
+
+// CancelBroadcastWithContext is like CancelBroadcast but allows you to timeout or cancel the request using context.Context.
+func (c *Client) CancelBroadcastWithContext(ctx context.Context, broadcastId int64, pageAccessToken string) error {
+	return c.doAction(ctx, "POST", c.apiURLs(pageAccessToken).BroadcastURL(broadcastId, ""), map[string]string{"operation": "cancel"})
+}
+
+// GetBroadcastStatus GETs the status of a broadcast, such as BroadcastStatusScheduled.
+func (c *Client) GetBroadcastStatus(broadcastId int64, pageAccessToken string) (BroadcastStatus, error) {
+	return c.GetBroadcastStatusWithContext(context.Background(), broadcastId, pageAccessToken)
+}
+
+// GetBroadcastStatusWithContext is like GetBroadcastStatus but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetBroadcastStatusWithContext(ctx context.Context, broadcastId int64, pageAccessToken string) (BroadcastStatus, error) {
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).BroadcastURL(broadcastId, "status"), nil)
+	if err != nil {
+		return "", err
+	}
+
+	response := &struct {
+		Status BroadcastStatus `json:"status"`
+		Error  *SendError      `json:"error"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return "", err
+	}
+
+	return response.Status, nil
+}
+
+// GetBroadcastInsights GETs the number of messages of a broadcast that were sent, delivered
+// and read, and the clicks and engagements they received.
+func (c *Client) GetBroadcastInsights(broadcastId int64, pageAccessToken string) (*BroadcastInsights, error) {
+	return c.GetBroadcastInsightsWithContext(context.Background(), broadcastId, pageAccessToken)
+}
+
+// GetBroadcastInsightsWithContext is like GetBroadcastInsights but allows you to timeout or cancel the request using context.Context.
+func (c *Client) GetBroadcastInsightsWithContext(ctx context.Context, broadcastId int64, pageAccessToken string) (*BroadcastInsights, error) {
+	metrics := strings.Join([]string{
+		BroadcastMetricMessagesSent,
+		BroadcastMetricMessagesDelivered,
+		BroadcastMetricMessagesRead,
+		BroadcastMetricClicks,
+		BroadcastMetricEngagements,
+	}, ",")
+
+	req, err := http.NewRequest("GET", c.apiURLs(pageAccessToken).BroadcastInsightsURL(broadcastId, metrics), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &struct {
+		Data []struct {
+			Name   string `json:"name"`
+			Values []struct {
+				Value int64 `json:"value"`
+			} `json:"values"`
+		} `json:"data"`
+	}{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return nil, err
+	}
+
+	insights := &BroadcastInsights{}
+	for _, metric := range response.Data {
+		if len(metric.Values) > 0 {
+			insights.setMetric(metric.Name, metric.Values[len(metric.Values)-1].Value)
+		}
+	}
+
+	return insights, nil
+}
+
+// actionResponse is the response to requests that perform an action rather than return data.
+type actionResponse struct {
+	Error *SendError `json:"error"`
+}
+
+// doAction sends a request whose response holds nothing but an indication of success, and
+// returns any error in sending or returned from Facebook.
+func (c *Client) doAction(ctx context.Context, method, url string, body interface{}) error {
+	req, err := c.newJSONRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+
+	response := &actionResponse{}
+	err = c.doRequest(ctx, req, response)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/*
+WithTimeout is context.WithTimeout, for setting a timeout on a single request made with one of
+the *WithContext methods of Client. Every request made by the Client is bound to its context,
+so it is abandoned when the deadline passes or the context is cancelled.
+
+	ctx, cancel := fbmessenger.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	response, err := client.SendWithContext(ctx, request, "YOUR_PAGE_ACCESS_TOKEN")
+*/
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (c *Client) apiURLs(pageAccessToken string) *apiURLBuilder {
+	return newAPIURLBuilder(c.URL, pageAccessToken)
+}
+
+// doRequest sends a request and decodes the response into responseStruct. An error reported
+// by Facebook is returned as a *SendError, with RetryAfter set from the Retry-After header.
+func (c *Client) doRequest(ctx context.Context, req *http.Request, responseStruct interface{}) error {
+	req = req.WithContext(ctx)
+
+	if c.debug != nil {
+		c.writeDebugRequest(req)
+	}
+
+	doer := c.httpDoer
+	if doer == nil {
+		doer = &http.Client{}
+	}
+
+	resp, err := doer.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if c.debug != nil {
+		c.writeDebugResponse(resp, body)
+	}
+
+	if sendError, _ := ParseSendError(body); sendError != nil {
+		sendError.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return sendError
+	}
+
+	return json.Unmarshal(body, responseStruct)
+}
+
+// parseRetryAfter converts a Retry-After header, in seconds or as an HTTP date, to how long
+// to wait. Zero is returned when the header is missing or not valid.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(header); err == nil && time.Until(at) > 0 {
+		return time.Until(at)
+	}
+
+	return 0
+}
+
+/*
+ParseSendError decodes an error returned from Facebook from the body of a Graph API response,
+for use when making Graph API calls the Client does not support. Nil is returned with no error
+when the body does not hold an error, for example when the call succeeded.
+*/
+func ParseSendError(body []byte) (*SendError, error) {
+	response := &actionResponse{}
+	err := json.Unmarshal(body, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Error, nil
+}
+
+// ParseSendErrorFromResponse is like ParseSendError but reads the body from a response,
+// which it closes.
+func ParseSendErrorFromResponse(resp *http.Response) (*SendError, error) {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSendError(body)
 }
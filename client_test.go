@@ -7,37 +7,43 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 
+	"bytes"
+	"errors"
 	"fmt"
+	"golang.org/x/net/context"
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"strings"
+	"time"
 )
 
 var _ = Describe("Client", func() {
-	Describe("Send", func() {
-		const (
-			pageAccessToken = "SOME_TOKEN"
-			userId          = "USER_ID"
-		)
+	const (
+		pageAccessToken = "SOME_TOKEN"
+		appAccessToken  = "APP_TOKEN"
+		userId          = "USER_ID"
+	)
 
-		var (
-			server *ghttp.Server
+	var (
+		server *ghttp.Server
 
-			client *Client
-		)
+		client *Client
+	)
 
-		BeforeEach(func() {
-			server = ghttp.NewServer()
+	BeforeEach(func() {
+		server = ghttp.NewServer()
 
-			client = &Client{
-				URL: server.URL(),
-			}
-		})
+		client = &Client{
+			URL: server.URL(),
+		}
+	})
 
-		AfterEach(func() {
-			server.Close()
-		})
+	AfterEach(func() {
+		server.Close()
+	})
 
+	Describe("Send", func() {
 		It("should POST json when sending a text message", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -65,6 +71,89 @@ var _ = Describe("Client", func() {
 			Expect(server.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("should encode the access token in the query string", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					func(w http.ResponseWriter, req *http.Request) {
+						Expect(req.URL.RawQuery).To(Equal("access_token=SOME%2BTOKEN%26WITH%3DSPECIAL"))
+						Expect(req.URL.Query().Get("access_token")).To(Equal("SOME+TOKEN&WITH=SPECIAL"))
+					},
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{RecipientId: userId}),
+				),
+			)
+
+			_, err := client.Send(TextMessage("Hello, world!").To(userId), "SOME+TOKEN&WITH=SPECIAL")
+			Expect(err).To(BeNil())
+		})
+
+		It("should send with the access token given as an option", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages", "access_token=OTHER_TOKEN"),
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{RecipientId: userId}),
+				),
+			)
+
+			response, err := client.SendWith(context.Background(), TextMessage("Hello, world!").To(userId), WithAccessToken("OTHER_TOKEN"))
+
+			Expect(err).To(BeNil())
+			Expect(response.RecipientId).To(Equal(userId))
+		})
+
+		It("should require an access token to send with options", func() {
+			_, err := client.SendWith(context.Background(), TextMessage("Hello, world!").To(userId))
+
+			Expect(err).To(MatchError("a page access token is required, set it with WithAccessToken"))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should return an error from facebook as a SendError", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.RespondWith(200, `{"error":{"code":200,"message":"Permissions error"},"recipient_id":"","message_id":""}`),
+				),
+			)
+
+			request := TextMessage("Hello, world!").To("USER_ID")
+			response, err := client.Send(request, pageAccessToken)
+
+			Expect(err).To(HaveOccurred())
+			var sendError *SendError
+			Expect(errors.As(err, &sendError)).To(BeTrue())
+			Expect(sendError.Code).To(Equal(200))
+			Expect(errors.Is(err, ErrPermission)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("Permissions error"))
+			Expect(response.Error).To(Equal(err))
+		})
+
+		It("should set how long to wait from the Retry-After header of a rate limiting error", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.RespondWith(429, `{"error":{"code":613,"message":"Calls to this api have exceeded the rate limit."}}`, http.Header{"Retry-After": []string{"30"}}),
+				),
+			)
+
+			_, err := client.Send(TextMessage("Hello, world!").To("USER_ID"), pageAccessToken)
+
+			Expect(errors.Is(err, ErrRateLimited)).To(BeTrue())
+			retryAfter, ok := IsRetryAfterError(err)
+			Expect(ok).To(BeTrue())
+			Expect(retryAfter).To(Equal(30 * time.Second))
+		})
+
+		It("should not report a retry for an error without a Retry-After header", func() {
+			server.AppendHandlers(ghttp.RespondWith(200, `{"error":{"code":200,"message":"Permissions error"}}`))
+
+			_, err := client.Send(TextMessage("Hello, world!").To("USER_ID"), pageAccessToken)
+
+			Expect(err).To(HaveOccurred())
+			_, ok := IsRetryAfterError(err)
+			Expect(ok).To(BeFalse())
+		})
+
 		It("should POST json when sending an image attached using the URL of the image", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -92,6 +181,30 @@ var _ = Describe("Client", func() {
 			Expect(server.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("should copy the correlation id from the request to the response without sending it", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"message":{"text":"Hello, world!"}}`),
+
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{
+						RecipientId: userId,
+						MessageId:   "mid.12345",
+					}),
+				),
+			)
+
+			request := TextMessage("Hello, world!").To("USER_ID").WithCorrelationId("ORDER_123")
+			response, err := client.Send(request, pageAccessToken)
+
+			if err != nil {
+				Fail(fmt.Sprintf("Error returned: %v", err))
+			}
+
+			Expect(response.MessageId).To(Equal("mid.12345"))
+			Expect(response.CorrelationId).To(Equal("ORDER_123"))
+		})
+
 		It("should POST form data when sending an image attached by uploading the image", func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -130,5 +243,1045 @@ var _ = Describe("Client", func() {
 
 			Expect(mediaType).To(Equal("multipart/form-data"))
 		})
+
+		It("should turn typing on, send the message and turn typing off in order", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_on"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"message":{"text":"Hello"}}`),
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{RecipientId: userId, MessageId: "mid.12345"}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages"),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_off"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+			)
+
+			start := time.Now()
+			response, err := TextMessage("Hello").TypingBefore(50*time.Millisecond).To(userId).Send(client, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(response.MessageId).To(Equal("mid.12345"))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+			Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+		})
+
+		It("should turn typing off when sending the message fails", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_on"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"message":{"text":"Hello"}}`),
+					ghttp.RespondWith(400, `{"error":{"message":"Invalid parameter","type":"OAuthException","code":100}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_off"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+			)
+
+			_, err := client.SendWithTyping(TextMessage("Hello").To(userId), time.Millisecond, pageAccessToken)
+
+			Expect(err).To(MatchError(ContainSubstring("Invalid parameter")))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("should turn typing off when the context is done while waiting", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_on"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"sender_action":"typing_off"}`),
+					ghttp.RespondWith(200, `{}`),
+				),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			_, err := client.SendWithTypingWithContext(ctx, TextMessage("Hello").To(userId), time.Minute, pageAccessToken)
+
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("should abandon a request when its context times out", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					func(w http.ResponseWriter, r *http.Request) {
+						time.Sleep(100 * time.Millisecond)
+					},
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{RecipientId: userId}),
+				),
+			)
+
+			ctx, cancel := WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := client.SendWithContext(ctx, TextMessage("Hello, world!").To(userId), pageAccessToken)
+
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+
+		It("should write the request and response to the debug writer", func() {
+			debug := &bytes.Buffer{}
+			client = NewClient(WithDebug(debug))
+			client.URL = server.URL()
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"},"message":{"text":"Hello, world!"}}`),
+
+					ghttp.RespondWith(200, `{"recipient_id":"USER_ID","message_id":"mid.12345"}`),
+				),
+			)
+
+			_, err := client.Send(TextMessage("Hello, world!").To(userId), pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(debug.String()).To(Equal("fbmessenger: debug mode enabled\n" +
+				"→ REQUEST: POST " + server.URL() + "/me/messages?access_token=[REDACTED]\n" +
+				"{\n  \"recipient\": {\n    \"id\": \"USER_ID\"\n  },\n  \"message\": {\n    \"text\": \"Hello, world!\"\n  }\n}\n" +
+				"← RESPONSE: 200 OK\n" +
+				"{\n  \"recipient_id\": \"USER_ID\",\n  \"message_id\": \"mid.12345\"\n}\n"))
+			Expect(debug.String()).NotTo(ContainSubstring(pageAccessToken))
+		})
+
+		It("should redact tokens in the bodies written to the debug writer", func() {
+			debug := &bytes.Buffer{}
+			client = NewClient(WithDebug(debug))
+			client.URL = server.URL()
+
+			server.AppendHandlers(ghttp.RespondWith(200, `{"access_token":"PAGE_ACCESS_TOKEN","id":"PAGE_ID"}`))
+
+			_, err := client.ExchangeForPageToken("USER_ACCESS_TOKEN", "PAGE_ID")
+
+			Expect(err).To(BeNil())
+			Expect(debug.String()).To(ContainSubstring("{\n  \"access_token\": \"[REDACTED]\",\n  \"id\": \"PAGE_ID\"\n}\n"))
+			Expect(debug.String()).NotTo(ContainSubstring("PAGE_ACCESS_TOKEN"))
+			Expect(debug.String()).NotTo(ContainSubstring("USER_ACCESS_TOKEN"))
+		})
+
+		It("should turn debug mode off for a nil writer", func() {
+			client = NewClient(WithDebug(nil))
+			client.URL = server.URL()
+
+			server.AppendHandlers(ghttp.RespondWith(200, `{"recipient_id":"USER_ID","message_id":"mid.12345"}`))
+
+			_, err := client.Send(TextMessage("Hello, world!").To(userId), pageAccessToken)
+
+			Expect(err).To(BeNil())
+		})
 	})
-})
+
+	Describe("Send File", func() {
+		var (
+			receivedFields   map[string]string
+			receivedFileName string
+			receivedFileType string
+			receivedFile     []byte
+		)
+
+		BeforeEach(func() {
+			receivedFields = map[string]string{}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messages", "access_token="+pageAccessToken),
+					func(w http.ResponseWriter, req *http.Request) {
+						reader, err := req.MultipartReader()
+						Expect(err).To(BeNil())
+
+						for {
+							part, err := reader.NextPart()
+							if err != nil {
+								break
+							}
+
+							partBytes, _ := ioutil.ReadAll(part)
+							if part.FormName() == "filedata" {
+								receivedFileName = part.FileName()
+								receivedFileType = part.Header.Get("Content-Type")
+								receivedFile = partBytes
+							} else {
+								receivedFields[part.FormName()] = string(partBytes)
+							}
+						}
+					},
+
+					ghttp.RespondWithJSONEncoded(200, &SendResponse{
+						RecipientId: userId,
+						MessageId:   "mid.12345",
+					}),
+				),
+			)
+		})
+
+		It("should POST the file with its detected content type as form data", func() {
+			response, err := client.SendFile(userId, "./sample-send-api-data/fb-logo.png", "", pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(response.MessageId).To(Equal("mid.12345"))
+
+			imageBytes, _ := ioutil.ReadFile("./sample-send-api-data/fb-logo.png")
+
+			Expect(receivedFields["recipient"]).To(MatchJSON(`{"id":"USER_ID"}`))
+			Expect(receivedFields["message"]).To(MatchJSON(`{"attachment":{"type":"image","payload":{}}}`))
+			Expect(receivedFileName).To(Equal("fb-logo.png"))
+			Expect(receivedFileType).To(Equal("image/png"))
+			Expect(receivedFile).To(Equal(imageBytes))
+		})
+
+		It("should send files that are not media with the file attachment type", func() {
+			_, err := client.SendFile(userId, "./sample-send-api-data/fb-logo.png", "application/pdf", pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(receivedFields["message"]).To(MatchJSON(`{"attachment":{"type":"file","payload":{}}}`))
+			Expect(receivedFileType).To(Equal("application/pdf"))
+		})
+
+		It("should return an error when the file does not exist", func() {
+			_, err := client.SendFile(userId, "./sample-send-api-data/missing.png", "", pageAccessToken)
+
+			Expect(err).ToNot(BeNil())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("User Profile", func() {
+		It("should GET the default fields of the user profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/"+userId, "fields=first_name,last_name,profile_pic,locale,timezone,gender&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, loadUserProfileString("user-profile.json")),
+				),
+			)
+
+			profile, err := client.GetUserProfile(userId, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(profile.FirstName).To(Equal("Peter"))
+			Expect(profile.PaymentPricePoints).To(BeNil())
+		})
+
+		It("should GET the payment fields of the user profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/"+userId, "fields=payment_price_points,requested_user_info&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, loadUserProfileString("user-profile-with-payment-info.json")),
+				),
+			)
+
+			profile, err := client.GetUserProfileFields(userId, pageAccessToken, "payment_price_points", "requested_user_info")
+
+			Expect(err).To(BeNil())
+			Expect(profile.PaymentPricePoints.Credits).To(HaveLen(2))
+			Expect(profile.PaymentPricePoints.Credits[1].Credits).To(Equal(int64(100)))
+			Expect(profile.PaymentPricePoints.Credits[1].LocalCurrency).To(Equal(9.99))
+			Expect(profile.RequestedUserInfo.ContactName).To(Equal("Peter Chang"))
+			Expect(profile.RequestedUserInfo.ShippingAddress.City).To(Equal("Menlo Park"))
+		})
+
+		It("should return a rate limiting error with how long to wait", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(429, `{"error":{"code":613,"message":"Calls to this api have exceeded the rate limit."}}`, http.Header{"Retry-After": []string{"30"}}),
+			)
+
+			_, err := client.GetUserProfileFields(userId, pageAccessToken, "first_name")
+
+			retryAfter, ok := IsRetryAfterError(err)
+			Expect(ok).To(BeTrue())
+			Expect(retryAfter).To(Equal(30 * time.Second))
+		})
+	})
+
+	Describe("Page Info", func() {
+		It("should GET the default fields of the page", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me", "fields=id,name,category&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"id":"PAGE_ID","name":"Peter's Hats","category":"Clothing Store"}`),
+				),
+			)
+
+			pageInfo, err := client.GetPageInfo(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(pageInfo).To(Equal(&PageInfo{Id: "PAGE_ID", Name: "Peter's Hats", Category: "Clothing Store"}))
+		})
+
+		It("should GET the picture and fan count of the page", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me", "fields=picture,fan_count&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"picture":{"data":{"height":50,"is_silhouette":false,"url":"https://scontent.xx.fbcdn.net/picture.png","width":50}},"fan_count":1024,"id":"PAGE_ID"}`),
+				),
+			)
+
+			pageInfo, err := client.GetPageInfo(pageAccessToken, "picture", "fan_count")
+
+			Expect(err).To(BeNil())
+			Expect(pageInfo.Picture).To(Equal(&PagePicture{URL: "https://scontent.xx.fbcdn.net/picture.png"}))
+			Expect(pageInfo.FanCount).To(Equal(int64(1024)))
+		})
+	})
+
+	Describe("ID Matching", func() {
+		BeforeEach(func() {
+			client = NewClient(WithAppAccessToken(appAccessToken))
+			client.URL = server.URL()
+		})
+
+		It("should GET the app-scoped id of a user using the page access token", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/PSID/ids_for_apps", "access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"id":"ASID","app":{"id":"APP_ID","name":"My App"}}]}`),
+				),
+			)
+
+			asid, err := client.GetASID("PSID", pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(asid).To(Equal("ASID"))
+		})
+
+		It("should GET the page-scoped id of a user using the app access token", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ASID/ids_for_pages", "page=PAGE_ID&access_token="+appAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"id":"PSID","page":{"id":"PAGE_ID","name":"My Page"}}]}`),
+				),
+			)
+
+			psid, err := client.GetPSIDFromASID("ASID", "PAGE_ID")
+
+			Expect(err).To(BeNil())
+			Expect(psid).To(Equal("PSID"))
+		})
+
+		It("should return an error when facebook returns no ids", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(200, `{"data":[]}`),
+			)
+
+			_, err := client.GetASID("PSID", pageAccessToken)
+
+			Expect(err).To(MatchError("facebook returned no app-scoped id for user PSID"))
+		})
+
+		It("should require an app access token to get a page-scoped id", func() {
+			client = &Client{URL: server.URL()}
+
+			_, err := client.GetPSIDFromASID("ASID", "PAGE_ID")
+
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("Access Tokens", func() {
+		BeforeEach(func() {
+			client = NewClient(WithAppAccessToken(appAccessToken))
+			client.URL = server.URL()
+		})
+
+		It("should GET the details of a token using the app access token", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/debug_token", "input_token="+pageAccessToken+"&access_token="+appAccessToken),
+
+					ghttp.RespondWith(200, `{"data":{"app_id":"APP_ID","type":"PAGE","expires_at":1352419328,"is_valid":true,"scopes":["pages_messaging"],"user_id":"USER_ID"}}`),
+				),
+			)
+
+			info, err := client.InspectToken(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(info).To(Equal(&TokenInfo{
+				AppId:     "APP_ID",
+				Type:      "PAGE",
+				ExpiresAt: time.Unix(1352419328, 0).UTC(),
+				IsValid:   true,
+				Scopes:    []string{"pages_messaging"},
+				UserId:    "USER_ID",
+			}))
+		})
+
+		It("should leave the expiry of a token that does not expire as the zero time", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(200, `{"data":{"app_id":"APP_ID","type":"PAGE","expires_at":0,"is_valid":true}}`),
+			)
+
+			info, err := client.InspectToken(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(info.ExpiresAt.IsZero()).To(BeTrue())
+		})
+
+		It("should require an app access token to inspect a token", func() {
+			client = &Client{URL: server.URL()}
+
+			_, err := client.InspectToken(pageAccessToken)
+
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should GET the access token of a page using a user access token", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/PAGE_ID", "fields=access_token&access_token=USER_TOKEN"),
+
+					ghttp.RespondWith(200, `{"access_token":"PAGE_TOKEN","id":"PAGE_ID"}`),
+				),
+			)
+
+			token, err := client.ExchangeForPageToken("USER_TOKEN", "PAGE_ID")
+
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("PAGE_TOKEN"))
+		})
+
+		It("should exchange a short-lived user access token using the app credentials", func() {
+			client = NewClient(WithAppCredentials("APP_ID", "APP_SECRET"))
+			client.URL = server.URL()
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/oauth/access_token", "grant_type=fb_exchange_token&client_id=APP_ID&client_secret=APP_SECRET&fb_exchange_token=SHORT_TOKEN"),
+
+					ghttp.RespondWith(200, `{"access_token":"LONG_TOKEN","token_type":"bearer","expires_in":5183944}`),
+				),
+			)
+
+			token, expiresAt, err := client.GetLongLivedToken("SHORT_TOKEN")
+
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("LONG_TOKEN"))
+			Expect(expiresAt).To(BeTemporally("~", time.Now().Add(5183944*time.Second), time.Minute))
+		})
+
+		It("should require app credentials to exchange a user access token", func() {
+			_, _, err := client.GetLongLivedToken("SHORT_TOKEN")
+
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should report a page access token accepted by facebook as valid", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me", "fields=id&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"id":"PAGE_ID"}`),
+				),
+			)
+
+			valid, err := client.IsTokenValid(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(valid).To(BeTrue())
+		})
+
+		It("should report a page access token rejected by facebook as not valid", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(400, `{"error":{"code":190,"type":"OAuthException","message":"Error validating access token"}}`),
+			)
+
+			valid, err := client.IsTokenValid(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(valid).To(BeFalse())
+		})
+	})
+
+	Describe("Batch Requests", func() {
+		It("should POST the requests as a batch with the page access token", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"batch":[{"method":"GET","relative_url":"USER_ID?fields=first_name%2Clast_name"},{"method":"GET","relative_url":"OTHER_ID?fields=first_name"}]}`),
+
+					ghttp.RespondWith(200, `[{"code":200,"body":"{\"first_name\":\"Peter\",\"last_name\":\"Chang\"}"},{"code":400,"body":"{\"error\":{\"code\":100,\"message\":\"Unsupported get request\"}}"}]`),
+				),
+			)
+
+			responses, err := client.BatchGet([]*BatchGetRequest{
+				UserProfileBatchRequest("USER_ID", "first_name", "last_name"),
+				UserProfileBatchRequest("OTHER_ID", "first_name"),
+			}, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(responses).To(HaveLen(2))
+
+			userProfile := &UserProfile{}
+			Expect(responses[0].Code).To(Equal(200))
+			Expect(responses[0].Unmarshal(userProfile)).To(Succeed())
+			Expect(userProfile.FirstName).To(Equal("Peter"))
+
+			Expect(responses[1].Code).To(Equal(400))
+			Expect(errors.Is(responses[1].Unmarshal(userProfile), ErrInvalidParameter)).To(BeTrue())
+		})
+
+		It("should return an error returned for the whole batch", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(400, `{"error":{"code":190,"message":"Invalid OAuth access token."}}`),
+			)
+
+			_, err := client.BatchGet([]*BatchGetRequest{UserProfileBatchRequest("USER_ID")}, pageAccessToken)
+
+			Expect(errors.Is(err, ErrAccessToken)).To(BeTrue())
+		})
+	})
+
+	Describe("Messenger Profile", func() {
+		It("should GET all known fields when none are given", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/messenger_profile", "fields=get_started,greeting,persistent_menu,whitelisted_domains,account_linking_url,ice_breakers,home_url&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, loadMessengerProfileString("messenger-profile-response.json")),
+				),
+			)
+
+			profile, err := client.GetMessengerProfile(pageAccessToken)
+
+			if err != nil {
+				Fail(fmt.Sprintf("Error returned: %v", err))
+			}
+
+			Expect(profile.GetStartedPayload).To(Equal("GET_STARTED_PAYLOAD"))
+			Expect(profile.GreetingTexts).To(HaveLen(2))
+			Expect(profile.GreetingTexts[1].Text).To(Equal("Bonjour {{user_first_name}}!"))
+			Expect(profile.PersistentMenus[0].CallToActions[0].Payload).To(Equal("HELP_PAYLOAD"))
+			Expect(profile.WhitelistedDomains).To(Equal([]string{"https://petersapparel.com"}))
+			Expect(profile.AccountLinkingURL).To(Equal("https://petersapparel.com/link"))
+		})
+
+		It("should GET only the requested fields", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/messenger_profile", "fields=greeting&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[]}`),
+				),
+			)
+
+			profile, err := client.GetMessengerProfile(pageAccessToken, "greeting")
+
+			Expect(err).To(BeNil())
+			Expect(profile.GreetingTexts).To(BeEmpty())
+		})
+
+		It("should DELETE the given fields", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/me/messenger_profile", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"fields":["greeting","get_started"]}`),
+
+					ghttp.RespondWith(200, `{"result":"success"}`),
+				),
+			)
+
+			Expect(client.DeleteMessengerProfileFields([]string{"greeting", "get_started"}, pageAccessToken)).To(BeNil())
+		})
+	})
+
+	Describe("Ice Breakers", func() {
+		It("should POST the ice breakers to the messenger profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messenger_profile", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"ice_breakers":[{"question":"Where are you located?","payload":"LOCATION_POSTBACK_PAYLOAD"}]}`),
+
+					ghttp.RespondWith(200, `{"result":"success"}`),
+				),
+			)
+
+			err := client.SetIceBreakers([]*IceBreaker{
+				&IceBreaker{Question: "Where are you located?", Payload: "LOCATION_POSTBACK_PAYLOAD"},
+			}, pageAccessToken)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should not POST more than 4 ice breakers", func() {
+			iceBreaker := &IceBreaker{Question: "Where are you located?", Payload: "LOCATION_POSTBACK_PAYLOAD"}
+
+			err := client.SetIceBreakers([]*IceBreaker{iceBreaker, iceBreaker, iceBreaker, iceBreaker, iceBreaker}, pageAccessToken)
+
+			Expect(err).To(MatchError("ice breakers has 5 items, exceeding the limit of 4"))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should not POST a question over 80 characters", func() {
+			iceBreaker := &IceBreaker{Question: strings.Repeat("a", 81), Payload: "LOCATION_POSTBACK_PAYLOAD"}
+
+			err := client.SetIceBreakers([]*IceBreaker{iceBreaker}, pageAccessToken)
+
+			Expect(err).To(MatchError("ice breakers[0]: IceBreaker.Question is 81 characters, exceeding the limit of 80"))
+		})
+
+		It("should GET the ice breakers from the messenger profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/messenger_profile", "fields=ice_breakers&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"ice_breakers":[{"question":"Where are you located?","payload":"LOCATION_POSTBACK_PAYLOAD"}]}]}`),
+				),
+			)
+
+			iceBreakers, err := client.GetIceBreakers(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(iceBreakers).To(HaveLen(1))
+			Expect(iceBreakers[0].Payload).To(Equal("LOCATION_POSTBACK_PAYLOAD"))
+		})
+
+		It("should DELETE the ice breakers from the messenger profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/me/messenger_profile"),
+					ghttp.VerifyJSON(`{"fields":["ice_breakers"]}`),
+
+					ghttp.RespondWith(200, `{"result":"success"}`),
+				),
+			)
+
+			Expect(client.DeleteIceBreakers(pageAccessToken)).To(BeNil())
+		})
+	})
+
+	Describe("Home URL", func() {
+		whitelistedDomainsHandler := ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/me/messenger_profile", "fields=whitelisted_domains&access_token="+pageAccessToken),
+
+			ghttp.RespondWith(200, `{"data":[{"whitelisted_domains":["https://petersapparel.com"]}]}`),
+		)
+
+		It("should POST the home url to the messenger profile", func() {
+			server.AppendHandlers(
+				whitelistedDomainsHandler,
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/messenger_profile", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"home_url":{"url":"https://petersapparel.com/home","webview_height_ratio":"tall","webview_share_button":"hide","in_test":true}}`),
+
+					ghttp.RespondWith(200, `{"result":"success"}`),
+				),
+			)
+
+			err := client.SetHomeURL(&HomeURL{
+				URL:                "https://petersapparel.com/home",
+				WebviewHeightRatio: "tall",
+				WebviewShareButton: "hide",
+				InTest:             true,
+			}, pageAccessToken)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should not POST a home url that does not use https", func() {
+			server.AppendHandlers(whitelistedDomainsHandler)
+
+			err := client.SetHomeURL(&HomeURL{URL: "http://petersapparel.com/home", WebviewHeightRatio: "tall"}, pageAccessToken)
+
+			Expect(err).To(MatchError(`HomeURL.URL "http://petersapparel.com/home" must use https`))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("should not POST a home url on a domain that is not whitelisted for the page", func() {
+			server.AppendHandlers(whitelistedDomainsHandler)
+
+			err := client.SetHomeURL(&HomeURL{URL: "https://example.com/home", WebviewHeightRatio: "tall"}, pageAccessToken)
+
+			Expect(err).To(MatchError(`HomeURL.URL "https://example.com/home" is not on a whitelisted domain`))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("should GET the home url from the messenger profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/messenger_profile", "fields=home_url&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"home_url":{"url":"https://petersapparel.com/home","webview_height_ratio":"tall","in_test":false}}]}`),
+				),
+			)
+
+			homeURL, err := client.GetHomeURL(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(homeURL.URL).To(Equal("https://petersapparel.com/home"))
+		})
+
+		It("should DELETE the home url from the messenger profile", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/me/messenger_profile"),
+					ghttp.VerifyJSON(`{"fields":["home_url"]}`),
+
+					ghttp.RespondWith(200, `{"result":"success"}`),
+				),
+			)
+
+			Expect(client.DeleteHomeURL(pageAccessToken)).To(BeNil())
+		})
+	})
+
+	Describe("Messenger Features", func() {
+		It("should GET the review status of the page's features", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/messaging_feature_review", "access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"feature":"payments","status":"APPROVED"},{"feature":"subscription_messaging","status":"pending"}]}`),
+				),
+			)
+
+			features, err := client.GetMessengerFeatures(pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(features).To(HaveLen(2))
+			Expect(features[0].Feature).To(Equal(FeaturePayments))
+
+			statuses := features.FeaturesMap()
+			Expect(statuses[FeatureSubscriptionMessaging]).To(Equal("pending"))
+			Expect(statuses.IsApproved(FeaturePayments)).To(BeTrue())
+			Expect(statuses.IsApproved(FeatureSubscriptionMessaging)).To(BeFalse())
+			Expect(statuses.IsApproved(FeatureNLP)).To(BeFalse())
+		})
+
+		It("should return an error returned from Facebook", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(200, `{"error":{"message":"Invalid OAuth access token.","type":"OAuthException","code":190}}`),
+			)
+
+			_, err := client.GetMessengerFeatures(pageAccessToken)
+
+			Expect(err).To(MatchError(ContainSubstring("Invalid OAuth access token.")))
+		})
+	})
+
+	Describe("Handover Protocol", func() {
+		It("should POST the target app and JSON encoded metadata when passing thread control", func() {
+			metadata := &HandoverMetadata{
+				Reason:     "escalation",
+				CustomData: map[string]interface{}{"ticket": "42"},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/pass_thread_control", "access_token="+pageAccessToken),
+					ghttp.VerifyJSONRepresenting(map[string]interface{}{
+						"recipient":     map[string]string{"id": userId},
+						"target_app_id": "APP_ID",
+						"metadata":      `{"reason":"escalation","custom_data":{"ticket":"42"}}`,
+					}),
+
+					ghttp.RespondWith(200, `{"success":true}`),
+				),
+			)
+
+			err := client.PassThreadControl(userId, "APP_ID", metadata, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(metadata.Marshal()).To(Equal(`{"reason":"escalation","custom_data":{"ticket":"42"}}`))
+		})
+
+		It("should omit metadata when taking thread control without it", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/take_thread_control"),
+					ghttp.VerifyJSON(`{"recipient":{"id":"USER_ID"}}`),
+
+					ghttp.RespondWith(200, `{"success":true}`),
+				),
+			)
+
+			Expect(client.TakeThreadControl(userId, nil, pageAccessToken)).To(BeNil())
+		})
+
+		It("should GET the thread owner", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/me/thread_owner", "recipient="+userId+"&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[{"thread_owner":{"app_id":"263902037430900","role":"primary_receiver"}}]}`),
+				),
+			)
+
+			owner, err := client.GetThreadOwner(userId, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(owner.AppId).To(Equal("263902037430900"))
+			Expect(owner.Role).To(Equal(AppRolePrimaryReceiver))
+			Expect(owner.IsPrimaryReceiver()).To(BeTrue())
+			Expect(owner.IsSecondaryReceiver()).To(BeFalse())
+		})
+
+		It("should return an error when there is no thread owner", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(200, `{"data":[]}`),
+			)
+
+			_, err := client.GetThreadOwner(userId, pageAccessToken)
+
+			Expect(err).To(MatchError("facebook returned no thread owner for user USER_ID"))
+		})
+
+		It("should return an error returned from Facebook when requesting thread control", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/request_thread_control"),
+
+					ghttp.RespondWith(200, `{"error":{"message":"Invalid app","type":"OAuthException","code":10}}`),
+				),
+			)
+
+			err := client.RequestThreadControl(userId, &HandoverMetadata{RequestId: "REQ_1"}, pageAccessToken)
+
+			Expect(err).To(MatchError(ContainSubstring("Invalid app")))
+		})
+	})
+
+	Describe("Broadcasts", func() {
+		It("should POST a message creative and broadcast it", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/message_creatives", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"messages":[{"text":"Hello, subscribers!"}]}`),
+
+					ghttp.RespondWith(200, `{"message_creative_id":938461089}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/broadcast_messages", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"message_creative_id":938461089}`),
+
+					ghttp.RespondWith(200, `{"broadcast_id":827}`),
+				),
+			)
+
+			creative, err := client.CreateMessageCreative([]*Message{&Message{Text: "Hello, subscribers!"}}, pageAccessToken)
+			Expect(err).To(BeNil())
+			Expect(creative.Id).To(Equal(int64(938461089)))
+
+			broadcastId, err := creative.Broadcast(client, pageAccessToken)
+			Expect(err).To(BeNil())
+			Expect(broadcastId).To(Equal(int64(827)))
+		})
+
+		It("should create and broadcast a message creative for each variant of an A/B test", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/message_creatives"),
+					ghttp.VerifyJSON(`{"messages":[{"text":"20% off hats!"}]}`),
+					ghttp.RespondWith(200, `{"message_creative_id":101}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/broadcast_messages"),
+					ghttp.VerifyJSON(`{"message_creative_id":101,"custom_label_id":1712444532121303}`),
+					ghttp.RespondWith(200, `{"broadcast_id":827}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/message_creatives"),
+					ghttp.VerifyJSON(`{"messages":[{"text":"Free shipping on hats!"}]}`),
+					ghttp.RespondWith(200, `{"message_creative_id":102}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/broadcast_messages"),
+					ghttp.VerifyJSON(`{"message_creative_id":102,"custom_label_id":1712444532121304}`),
+					ghttp.RespondWith(200, `{"broadcast_id":828}`),
+				),
+			)
+
+			broadcastIds, err := client.ABTestBroadcast([]*ABTestBroadcast{
+				{Variant: "discount", Messages: []*Message{{Text: "20% off hats!"}}, CustomLabelId: 1712444532121303},
+				{Variant: "shipping", Messages: []*Message{{Text: "Free shipping on hats!"}}, CustomLabelId: 1712444532121304},
+			}, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(broadcastIds).To(Equal(map[string]int64{"discount": 827, "shipping": 828}))
+		})
+
+		It("should not broadcast an A/B test with variants that are not unique", func() {
+			_, err := client.ABTestBroadcast([]*ABTestBroadcast{
+				{Variant: "discount", Messages: []*Message{{Text: "20% off hats!"}}},
+				{Variant: "discount", Messages: []*Message{{Text: "Free shipping on hats!"}}},
+			}, pageAccessToken)
+
+			Expect(err).To(MatchError(`variants[1]: ABTestBroadcast.Variant "discount" is not unique`))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should GET the insights of each variant of an A/B test", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/827/insights/messages_sent,messages_delivered,messages_read,clicks,engagements"),
+					ghttp.RespondWith(200, `{"data":[{"name":"messages_read","period":"lifetime","values":[{"value":450}]}]}`),
+				),
+			)
+
+			insights, err := client.GetABTestInsights(map[string]int64{"discount": 827}, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(insights).To(HaveKey("discount"))
+			Expect(insights["discount"].UniqueImpressions).To(Equal(int64(450)))
+		})
+
+		It("should POST a scheduled broadcast with the schedule time in seconds", func() {
+			at := time.Now().Add(time.Hour)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/me/broadcast_messages", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(fmt.Sprintf(`{"message_creative_id":938461089,"schedule_time":%v}`, at.Unix())),
+
+					ghttp.RespondWith(200, `{"broadcast_id":827}`),
+				),
+			)
+
+			broadcastId, err := client.ScheduleBroadcast(938461089, at, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(broadcastId).To(Equal(int64(827)))
+		})
+
+		It("should not POST a broadcast scheduled too soon", func() {
+			_, err := client.ScheduleBroadcast(938461089, time.Now(), pageAccessToken)
+
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("should POST a cancellation of a broadcast", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/827", "access_token="+pageAccessToken),
+					ghttp.VerifyJSON(`{"operation":"cancel"}`),
+
+					ghttp.RespondWith(200, `{"success":true}`),
+				),
+			)
+
+			err := client.CancelBroadcast(827, pageAccessToken)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should GET the status of a broadcast", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/827", "fields=status&access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"status":"SCHEDULED","id":"827"}`),
+				),
+			)
+
+			status, err := client.GetBroadcastStatus(827, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(BroadcastStatusScheduled))
+		})
+
+		It("should GET the insights of a broadcast and flatten the metrics", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/827/insights/messages_sent,messages_delivered,messages_read,clicks,engagements", "access_token="+pageAccessToken),
+
+					ghttp.RespondWith(200, `{"data":[
+						{"name":"messages_sent","period":"lifetime","values":[{"value":1000,"end_time":"2026-10-13T07:00:00+0000"}]},
+						{"name":"messages_delivered","period":"lifetime","values":[{"value":900,"end_time":"2026-10-13T07:00:00+0000"}]},
+						{"name":"messages_read","period":"lifetime","values":[{"value":450,"end_time":"2026-10-13T07:00:00+0000"}]},
+						{"name":"clicks","period":"lifetime","values":[{"value":60,"end_time":"2026-10-13T07:00:00+0000"}]},
+						{"name":"engagements","period":"lifetime","values":[{"value":90,"end_time":"2026-10-13T07:00:00+0000"}]}
+					]}`),
+				),
+			)
+
+			insights, err := client.GetBroadcastInsights(827, pageAccessToken)
+
+			Expect(err).To(BeNil())
+			Expect(*insights).To(Equal(BroadcastInsights{ReachEstimate: 1000, TotalImpressions: 900, UniqueImpressions: 450, Clicks: 60, Engagements: 90}))
+			Expect(insights.EngagementRate()).To(BeNumerically("~", 0.1))
+			Expect((&BroadcastInsights{Engagements: 90}).EngagementRate()).To(BeZero())
+		})
+	})
+
+	Describe("Send Errors", func() {
+		It("should parse errors in each range of codes", func() {
+			for _, code := range []int{2, 100, 200, 613} {
+				body := fmt.Sprintf(`{"error":{"message":"Error %v","type":"OAuthException","code":%v,"error_data":"data","fbtrace_id":"AbCd123"}}`, code, code)
+
+				sendError, err := ParseSendError([]byte(body))
+
+				Expect(err).To(BeNil())
+				Expect(sendError).To(Equal(&SendError{
+					Message:   fmt.Sprintf("Error %v", code),
+					Type:      "OAuthException",
+					Code:      code,
+					ErrorData: "data",
+					FBTraceId: "AbCd123",
+				}))
+			}
+		})
+
+		It("should return nil when there is no error", func() {
+			sendError, err := ParseSendError([]byte(`{"recipient_id":"USER_ID","message_id":"mid.12345"}`))
+
+			Expect(err).To(BeNil())
+			Expect(sendError).To(BeNil())
+		})
+
+		It("should return an error when the body is not json", func() {
+			_, err := ParseSendError([]byte("Bad Gateway"))
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should parse an error from a response", func() {
+			resp := &http.Response{
+				Body: ioutil.NopCloser(strings.NewReader(`{"error":{"message":"Invalid parameter","code":100}}`)),
+			}
+
+			sendError, err := ParseSendErrorFromResponse(resp)
+
+			Expect(err).To(BeNil())
+			Expect(sendError.Code).To(Equal(100))
+		})
+	})
+})
+
+func loadMessengerProfileString(fileName string) string {
+	fileBytes, err := ioutil.ReadFile("./sample-messenger-profile-data/" + fileName)
+	if err != nil {
+		Fail(fmt.Sprintf("Error reading file \"%v\": %v", fileName, err))
+	}
+
+	return string(fileBytes)
+}
+
+func loadUserProfileString(fileName string) string {
+	fileBytes, err := ioutil.ReadFile("./sample-user-profile-data/" + fileName)
+	if err != nil {
+		Fail(fmt.Sprintf("Error reading file \"%v\": %v", fileName, err))
+	}
+
+	return string(fileBytes)
+}
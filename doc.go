@@ -51,10 +51,11 @@ Client Usage
 	// Then send your request and handle errors in sending, and errors returned from Facebook.
 
 	response, err := client.Send(request, "YOUR_PAGE_ACCESS_TOKEN")
-	if err != nil {
-		//Got an error. Request never got to Facebook.
-	} else if response.Error != nil {
+	var sendError *SendError
+	if errors.As(err, &sendError) {
 		//Request got to Facebook. Facebook returned an error.
+	} else if err != nil {
+		//Got an error. Request never got to Facebook.
 	} else {
 		//Hooray!
 	}
@@ -0,0 +1,222 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+)
+
+// roundTripCase names a canonical payload and a function returning the model it should
+// unmarshal into. Attachment payloads are pre-populated so they unmarshal into their
+// concrete type rather than a map.
+type roundTripCase struct {
+	fileName string
+	newModel func() interface{}
+}
+
+func sendRequestWithPayload(payload interface{}) func() interface{} {
+	return func() interface{} {
+		return &SendRequest{Message: Message{Attachment: &Attachment{Payload: payload}}}
+	}
+}
+
+func newCallback() interface{}     { return &Callback{} }
+func newSendRequest() interface{}  { return &SendRequest{} }
+func newSendResponse() interface{} { return &SendResponse{} }
+func newUserProfile() interface{}  { return &UserProfile{} }
+
+var _ = Describe("JSON Round Trip", func() {
+	cases := []roundTripCase{
+		{"sample-callback-data/text-message.json", newCallback},
+		{"sample-callback-data/message-with-quick-reply.json", newCallback},
+		{"sample-callback-data/message-with-image-attachment.json", newCallback},
+		{"sample-callback-data/message-with-location-attachment.json", newCallback},
+		{"sample-callback-data/message-with-location-attachment-payload-title.json", newCallback},
+		{"sample-callback-data/message-with-sticker.json", newCallback},
+		{"sample-callback-data/message-reply.json", newCallback},
+		{"sample-callback-data/delivery.json", newCallback},
+		{"sample-callback-data/read.json", newCallback},
+		{"sample-callback-data/messaging-feedback.json", newCallback},
+		{"sample-callback-data/postback.json", newCallback},
+		{"sample-callback-data/postback-with-referral.json", newCallback},
+		{"sample-callback-data/authentication.json", newCallback},
+		{"sample-callback-data/one-time-notification-optin.json", newCallback},
+		{"sample-callback-data/reaction.json", newCallback},
+		{"sample-callback-data/message-delete.json", newCallback},
+		{"sample-callback-data/instagram-message.json", newCallback},
+		{"sample-callback-data/instagram-story-mention.json", newCallback},
+		{"sample-callback-data/instagram-story-reply.json", newCallback},
+		{"sample-callback-data/multiple-entries.json", newCallback},
+		{"sample-callback-data/message-echo-with-persona.json", newCallback},
+
+		{"sample-send-api-data/text-message.json", newSendRequest},
+		{"sample-send-api-data/text-message-to-phone-number.json", newSendRequest},
+		{"sample-send-api-data/text-message-to-phone-number-with-name.json", newSendRequest},
+		{"sample-send-api-data/text-message-regular.json", newSendRequest},
+		{"sample-send-api-data/text-message-silent-push.json", newSendRequest},
+		{"sample-send-api-data/text-message-no-push.json", newSendRequest},
+		{"sample-send-api-data/text-message-with-text-quick-replies.json", newSendRequest},
+		{"sample-send-api-data/text-message-with-text-and-image-quick-replies.json", newSendRequest},
+		{"sample-send-api-data/text-message-with-location-quick-reply.json", newSendRequest},
+		{"sample-send-api-data/message-with-image-attachment.json", sendRequestWithPayload(&ResourcePayload{})},
+		{"sample-send-api-data/message-with-reusable-image-attachment.json", sendRequestWithPayload(&ResourcePayload{})},
+		{"sample-send-api-data/message-with-button-attachment.json", sendRequestWithPayload(&ButtonPayload{})},
+		{"sample-send-api-data/message-with-call-share-and-login-buttons.json", sendRequestWithPayload(&ButtonPayload{})},
+		{"sample-send-api-data/message-with-generic-template-attachment.json", sendRequestWithPayload(&GenericPayload{})},
+		{"sample-send-api-data/message-with-customer-feedback-template.json", sendRequestWithPayload(&CustomerFeedbackPayload{})},
+		{"sample-send-api-data/message-with-square-generic-template-attachment.json", sendRequestWithPayload(&GenericPayload{})},
+		{"sample-send-api-data/message-with-receipt-attachment.json", sendRequestWithPayload(&ReceiptPayload{})},
+		{"sample-send-api-data/message-with-audio-attachment.json", sendRequestWithPayload(&ResourcePayload{})},
+		{"sample-send-api-data/message-with-list-template-attachment.json", sendRequestWithPayload(&ListPayload{})},
+		{"sample-send-api-data/message-with-media-template-attachment.json", sendRequestWithPayload(&MediaPayload{})},
+		{"sample-send-api-data/message-with-generic-template-attachment-and-tag.json", sendRequestWithPayload(&GenericPayload{})},
+		{"sample-send-api-data/airline-flight-info.json", func() interface{} { return &FlightInfo{} }},
+		{"sample-send-api-data/airline-passenger-info.json", func() interface{} { return &PassengerInfo{} }},
+		{"sample-send-api-data/airline-passenger-segment-info.json", func() interface{} { return &PassengerSegmentInfo{} }},
+		{"sample-send-api-data/airline-price-info.json", func() interface{} { return &PriceInfo{} }},
+		{"sample-send-api-data/message-with-one-time-notification-request.json", sendRequestWithPayload(&OneTimeNotificationRequestPayload{})},
+		{"sample-send-api-data/successful-response.json", newSendResponse},
+		{"sample-send-api-data/error-response.json", newSendResponse},
+
+		{"sample-user-profile-data/user-profile.json", newUserProfile},
+		{"sample-user-profile-data/user-profile-with-payment-info.json", newUserProfile},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		It(fmt.Sprintf("should round trip %v", c.fileName), func() {
+			expectRoundTrip(c.fileName, c.newModel())
+		})
+	}
+})
+
+/*
+expectRoundTrip unmarshals the file into model, marshals it again and compares the two
+documents. Every key in the original must survive with the same value, which catches json
+tags that don't match Facebook's field names. Keys only present after the round trip are
+allowed as long as they hold zero values, since not every model field is omitempty.
+*/
+func expectRoundTrip(fileName string, model interface{}) {
+	original, err := ioutil.ReadFile("./" + fileName)
+	if err != nil {
+		Fail(fmt.Sprintf("Error reading file \"%v\": %v", fileName, err))
+	}
+
+	err = json.Unmarshal(original, model)
+	if err != nil {
+		Fail(fmt.Sprintf("Error unmarshaling \"%v\": %v", fileName, err))
+	}
+
+	roundTripped, err := json.Marshal(model)
+	if err != nil {
+		Fail(fmt.Sprintf("Error marshaling \"%v\": %v", fileName, err))
+	}
+
+	Expect(compareDocuments("$", decodeGeneric(original), decodeGeneric(roundTripped))).To(BeEmpty())
+}
+
+func decodeGeneric(data []byte) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		Fail(fmt.Sprintf("Error decoding json: %v", err))
+	}
+
+	return v
+}
+
+func compareDocuments(path string, expected, actual interface{}) []string {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		actual, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%v: expected an object, got %v", path, actual)}
+		}
+
+		var differences []string
+		for key, expectedValue := range expected {
+			actualValue, ok := actual[key]
+			if !ok {
+				differences = append(differences, fmt.Sprintf("%v.%v: missing after round trip", path, key))
+				continue
+			}
+
+			differences = append(differences, compareDocuments(path+"."+key, expectedValue, actualValue)...)
+		}
+
+		for key, actualValue := range actual {
+			if _, ok := expected[key]; !ok && !isZeroJSONValue(actualValue) {
+				differences = append(differences, fmt.Sprintf("%v.%v: unexpected value %v after round trip", path, key, actualValue))
+			}
+		}
+
+		return differences
+	case []interface{}:
+		actual, ok := actual.([]interface{})
+		if !ok || len(actual) != len(expected) {
+			return []string{fmt.Sprintf("%v: expected %v, got %v", path, expected, actual)}
+		}
+
+		var differences []string
+		for i := range expected {
+			differences = append(differences, compareDocuments(fmt.Sprintf("%v[%v]", path, i), expected[i], actual[i])...)
+		}
+
+		return differences
+	case json.Number:
+		actual, ok := actual.(json.Number)
+		if !ok {
+			return []string{fmt.Sprintf("%v: expected %v, got %v", path, expected, actual)}
+		}
+
+		expectedFloat, _ := expected.Float64()
+		actualFloat, _ := actual.Float64()
+		if expectedFloat != actualFloat {
+			return []string{fmt.Sprintf("%v: expected %v, got %v", path, expected, actual)}
+		}
+
+		return nil
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return []string{fmt.Sprintf("%v: expected %v, got %v", path, expected, actual)}
+		}
+
+		return nil
+	}
+}
+
+func isZeroJSONValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		f, _ := v.Float64()
+		return f == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, value := range v {
+			if !isZeroJSONValue(value) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -1,8 +1,18 @@
 package fbmessenger
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 /*------------------------------------------------------
@@ -10,6 +20,8 @@ Send API
 ------------------------------------------------------*/
 
 // TextMessage is a fluent helper method for creating a SendRequest containing a text message.
+// The length of the text is not checked; use NewTextMessage or SendRequest.Validate to check
+// it against MaxTextLength, as Facebook rejects longer messages.
 func TextMessage(text string) *SendRequest {
 	return &SendRequest{
 		Message: Message{
@@ -18,6 +30,28 @@ func TextMessage(text string) *SendRequest {
 	}
 }
 
+// NewTextMessage is like TextMessage but returns an error when the text is longer than
+// MaxTextLength characters.
+func NewTextMessage(text string) (*SendRequest, error) {
+	if err := validateLength("Message.Text", text, MaxTextLength); err != nil {
+		return nil, err
+	}
+
+	return TextMessage(text), nil
+}
+
+// MessageTo is a shortcut for TextMessage(text).To(userId), creating a SendRequest with a
+// text message to a user. It only builds the request; send it with Client.Send.
+func MessageTo(userId, text string) *SendRequest {
+	return TextMessage(text).To(userId)
+}
+
+// TextMessageWithReplies is a shortcut for TextMessage(text).WithQuickReplies(replies...),
+// creating a SendRequest with a text message and quick replies in one call.
+func TextMessageWithReplies(text string, replies ...*QuickReply) *SendRequest {
+	return TextMessage(text).WithQuickReplies(replies...)
+}
+
 /*
 ImageMessage is a fluent helper method for creating a SendRequest containing a message with
 an image attached using the URL of the image.
@@ -25,12 +59,33 @@ an image attached using the URL of the image.
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference/image-attachment
 */
 func ImageMessage(url string) *SendRequest {
+	return &SendRequest{
+		Message: Message{
+			Attachment: ImageAttachment(url),
+		},
+	}
+}
+
+// ImageAttachmentOptions are the optional settings of an image attached with ImageMessageWithOptions.
+type ImageAttachmentOptions struct {
+	// IsReusable asks Facebook to return an attachment id in the SendResponse, so the image
+	// can be sent again without uploading it again.
+	IsReusable bool
+
+	// AspectRatio sets how the image is rendered, where supported, such as on Instagram.
+	AspectRatio ImageAspectRatio
+}
+
+// ImageMessageWithOptions is like ImageMessage but with the options of the attachment set.
+func ImageMessageWithOptions(url string, opts ImageAttachmentOptions) *SendRequest {
 	return &SendRequest{
 		Message: Message{
 			Attachment: &Attachment{
 				Type: "image",
 				Payload: ResourcePayload{
-					URL: url,
+					URL:              url,
+					IsReusable:       opts.IsReusable,
+					ImageAspectRatio: opts.AspectRatio,
 				},
 			},
 		},
@@ -65,26 +120,68 @@ func ImageDataMessage(data []byte, contentType string) *SendRequest {
 }
 
 /*
-ButtonTemplateMessage is a fluent helper method for creating a SendRequest containing text
-and buttons to request input from the user.
+AudioMessage is a fluent helper method for creating a SendRequest containing a message with
+an audio file attached using the URL of the file.
 
-See https://developers.facebook.com/docs/messenger-platform/send-api-reference/button-template
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/audio-attachment
 */
-func ButtonTemplateMessage(text string, buttons ...*Button) *SendRequest {
+func AudioMessage(url string) *SendRequest {
 	return &SendRequest{
 		Message: Message{
-			Attachment: &Attachment{
-				Type: "template",
-				Payload: ButtonPayload{
-					TemplateType: "button",
-					Text:         text,
-					Buttons:      buttons,
-				},
-			},
+			Attachment: AudioAttachment(url),
+		},
+	}
+}
+
+/*
+VideoMessage is a fluent helper method for creating a SendRequest containing a message with
+a video attached using the URL of the video.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/video-attachment
+*/
+func VideoMessage(url string) *SendRequest {
+	return &SendRequest{
+		Message: Message{
+			Attachment: VideoAttachment(url),
+		},
+	}
+}
+
+/*
+TemplateMessage is a fluent helper method for creating a SendRequest containing a structured
+message built from any template payload. It supports templates this package has no helper
+for: define a struct with the fields of the template, including template_type, and implement
+TemplateTyper to return the template type.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/templates
+*/
+func TemplateMessage(payload TemplateTyper) *SendRequest {
+	return &SendRequest{
+		Message: Message{
+			Attachment: TemplateAttachment(payload),
 		},
 	}
 }
 
+/*
+ButtonTemplateMessage is a fluent helper method for creating a SendRequest containing text
+and buttons to request input from the user.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/button-template
+*/
+func ButtonTemplateMessage(text string, buttons ...*Button) *SendRequest {
+	return TemplateMessage(ButtonPayload{
+		TemplateType: TemplateTypeButton,
+		Text:         text,
+		Buttons:      buttons,
+	})
+}
+
+// ButtonMessage is a shorter name for ButtonTemplateMessage.
+func ButtonMessage(text string, buttons ...*Button) *SendRequest {
+	return ButtonTemplateMessage(text, buttons...)
+}
+
 /*
 GenericTemplateMessage is a fluent helper method for creating a SendRequest containing
 a carousel of elements, each composed of an image attachment, short description and
@@ -93,17 +190,108 @@ buttons to request input from the user.
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference/generic-template
 */
 func GenericTemplateMessage(elements ...*GenericElement) *SendRequest {
-	return &SendRequest{
-		Message: Message{
-			Attachment: &Attachment{
-				Type: "template",
-				Payload: GenericPayload{
-					TemplateType: "generic",
-					Elements:     elements,
-				},
-			},
-		},
-	}
+	return TemplateMessage(GenericPayload{
+		TemplateType: TemplateTypeGeneric,
+		Elements:     elements,
+	})
+}
+
+// GenericTemplateMessageSquare is a fluent helper method for creating a SendRequest containing
+// a generic template message with square images, rather than the default horizontal ones.
+func GenericTemplateMessageSquare(elements ...*GenericElement) *SendRequest {
+	sr := GenericTemplateMessage(elements...)
+
+	payload := sr.Message.Attachment.Payload.(GenericPayload)
+	sr.Message.Attachment.Payload = *payload.WithImageAspectRatio(ImageAspectRatioSquare)
+
+	return sr
+}
+
+/*
+ListTemplateMessage is a fluent helper method for creating a SendRequest containing a
+vertical list of elements. The style sets how the first element is rendered, either
+ListStyleLarge or ListStyleCompact, and may be empty to use Facebook's default.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/list-template
+*/
+func ListTemplateMessage(style ListTemplateStyle, elements ...*ListElement) *SendRequest {
+	return TemplateMessage(ListPayload{
+		TemplateType:    TemplateTypeList,
+		TopElementStyle: style,
+		Elements:        elements,
+	})
+}
+
+/*
+ListTemplateMessageWithAction is a fluent helper method for creating a SendRequest containing
+a list template message with a single global button, such as "View More", rendered below
+the list.
+*/
+func ListTemplateMessageWithAction(style ListTemplateStyle, action *Button, elements ...*ListElement) *SendRequest {
+	sr := ListTemplateMessage(style, elements...)
+
+	payload := sr.Message.Attachment.Payload.(ListPayload)
+	payload.Buttons = []*Button{action}
+	sr.Message.Attachment.Payload = payload
+
+	return sr
+}
+
+// LargeListTemplateMessage is a fluent helper method for creating a SendRequest containing
+// a list template message with the first element rendered large, with a cover image.
+func LargeListTemplateMessage(elements ...*ListElement) *SendRequest {
+	return ListTemplateMessage(ListStyleLarge, elements...)
+}
+
+// CompactListTemplateMessage is a fluent helper method for creating a SendRequest containing
+// a list template message with every element rendered the same size.
+func CompactListTemplateMessage(elements ...*ListElement) *SendRequest {
+	return ListTemplateMessage(ListStyleCompact, elements...)
+}
+
+/*
+MediaTemplateMessage is a fluent helper method for creating a SendRequest containing an
+image or video with optional buttons.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/template/media
+*/
+func MediaTemplateMessage(elements ...*MediaElement) *SendRequest {
+	return TemplateMessage(MediaPayload{
+		TemplateType: TemplateTypeMedia,
+		Elements:     elements,
+	})
+}
+
+/*
+OneTimeNotificationRequestMessage is a fluent helper method for creating a SendRequest
+asking the user for permission to send them one message outside of the 24 hour window.
+The title is shown to the user along with a "Notify Me" button.
+
+When the user taps the button, an OptIn callback is received with Type "one_time_notif_req",
+the payload of this message as its Payload and a OneTimeNotifToken. Send the follow up
+message to a Recipient with that token, which can only be used once.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/one-time-notification
+*/
+func OneTimeNotificationRequestMessage(title, payload string) *SendRequest {
+	return TemplateMessage(OneTimeNotificationRequestPayload{
+		TemplateType: TemplateTypeOneTimeNotif,
+		Title:        title,
+		Payload:      payload,
+	})
+}
+
+/*
+CustomerFeedbackMessage is a fluent helper method for creating a SendRequest asking the
+user to rate their experience and answer follow up questions. The TemplateType of the
+payload is set. The answers are received in a callback; use ParseFeedbackCallback to get them.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/templates/customer-feedback-template
+*/
+func CustomerFeedbackMessage(payload CustomerFeedbackPayload) *SendRequest {
+	payload.TemplateType = TemplateTypeCustomerFeedback
+
+	return TemplateMessage(payload)
 }
 
 /*
@@ -113,26 +301,13 @@ a detailed order confirmation.
 https://developers.facebook.com/docs/messenger-platform/send-api-reference/receipt-template
 */
 func ReceiptTemplateMessage(header *ReceiptHeader, summary *ReceiptSummary, elements ...*ReceiptElement) *SendRequest {
-	payload := &ReceiptPayload{
-		TemplateType:  "receipt",
-		RecipientName: header.RecipientName,
-		OrderNumber:   header.OrderNumber,
-		Currency:      header.Currency,
-		PaymentMethod: header.PaymentMethod,
-		OrderURL:      header.OrderURL,
-		Timestamp:     header.Timestamp,
-		Elements:      elements,
-		Summary:       summary,
-	}
+	payload := NewReceiptPayload(header.RecipientName, header.OrderNumber, header.Currency, header.PaymentMethod)
+	payload.OrderURL = header.OrderURL
+	payload.Timestamp = header.Timestamp
+	payload.Elements = elements
+	payload.Summary = summary
 
-	return &SendRequest{
-		Message: Message{
-			Attachment: &Attachment{
-				Type:    "template",
-				Payload: payload,
-			},
-		},
-	}
+	return TemplateMessage(payload)
 }
 
 // ReceiptHeader holds just the top level fields for a ReceiptPayload. For use with
@@ -143,7 +318,30 @@ type ReceiptHeader struct {
 	Currency      string
 	PaymentMethod string
 	OrderURL      string
-	Timestamp     string
+	Timestamp     int64
+}
+
+// NewReceiptPayload creates a ReceiptPayload with the required top level fields set. The
+// elements and summary, which are also required, must be set before it is sent.
+func NewReceiptPayload(recipientName, orderNumber, currency, paymentMethod string) *ReceiptPayload {
+	return &ReceiptPayload{
+		TemplateType:  TemplateTypeReceipt,
+		RecipientName: recipientName,
+		OrderNumber:   orderNumber,
+		Currency:      currency,
+		PaymentMethod: paymentMethod,
+	}
+}
+
+/*
+SetOrderDate is a fluent helper method for setting the Timestamp of the receipt, which
+Facebook displays as the order date. It is a mutator and returns the same ReceiptPayload
+on which it is called to support method chaining.
+*/
+func (p *ReceiptPayload) SetOrderDate(t time.Time) *ReceiptPayload {
+	p.Timestamp = t.Unix()
+
+	return p
 }
 
 /*
@@ -192,18 +390,188 @@ func PostbackButton(title, payload string) *Button {
 	}
 }
 
+/*
+NewPostbackPayload encodes a value as a JSON string for use as the payload of a
+PostbackButton. Decode it from the Postback callback with Postback.DecodePayload.
+
+	payload, err := NewPostbackPayload(&Order{Action: "buy", ProductId: 42})
+	button := PostbackButton("Buy", payload)
+*/
+func NewPostbackPayload(v interface{}) (string, error) {
+	payloadBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payloadBytes), nil
+}
+
+// CallButton is a fluent helper method for creating a button with type "phone_number"
+// that calls the phone number, which must be in E.164 format such as "+16505551234".
+func CallButton(title, phoneNumber string) *Button {
+	return &Button{
+		Type:    "phone_number",
+		Title:   title,
+		Payload: phoneNumber,
+	}
+}
+
+// ShareButton is a fluent helper method for creating a button with type "element_share"
+// that lets the user share the message with their friends.
+func ShareButton() *Button {
+	return &Button{
+		Type: "element_share",
+	}
+}
+
+// LoginButton is a fluent helper method for creating a button with type "account_link"
+// that starts the account linking flow at the URL.
+func LoginButton(url string) *Button {
+	return &Button{
+		Type: "account_link",
+		URL:  url,
+	}
+}
+
+// LogoutButton is a fluent helper method for creating a button with type "account_unlink"
+// that unlinks the user's account.
+func LogoutButton() *Button {
+	return &Button{
+		Type: "account_unlink",
+	}
+}
+
+// GamePlayButton is a fluent helper method for creating a button with type "game_play"
+// that launches an Instant Game. The payload is sent to the game when it starts.
+func GamePlayButton(title, payload string) *Button {
+	return &Button{
+		Type:    "game_play",
+		Title:   title,
+		Payload: payload,
+	}
+}
+
 // To is a fluent helper method for setting Recipient. It is a mutator
 // and returns the same SendRequest on which it is called to support method chaining.
 func (sr *SendRequest) To(userId string) *SendRequest {
 	sr.Recipient = Recipient{Id: userId}
+	sr.phoneNumberErr = nil
 
 	return sr
 }
 
-// ToPhoneNumber is a fluent helper method for setting Recipient. It
-// is a mutator and returns the same SendRequest on which it is called to support method chaining.
+// ToRecipient is a fluent helper method for setting Recipient to one built elsewhere, such
+// as one with a UserRef. It is a mutator and returns the same SendRequest on which it is
+// called to support method chaining.
+func (sr *SendRequest) ToRecipient(recipient Recipient) *SendRequest {
+	sr.Recipient = recipient
+	sr.phoneNumberErr = nil
+
+	return sr
+}
+
+/*
+ToPhoneNumber is a fluent helper method for setting Recipient. The phone number is converted
+to E.164 format with NormalizePhoneNumber. When it can't be converted, it is used as given and
+Validate returns the error; use ToPhoneNumberRaw for numbers that are already normalized.
+It is a mutator and returns the same SendRequest on which it is called to support method chaining.
+*/
 func (sr *SendRequest) ToPhoneNumber(phoneNumber string) *SendRequest {
+	normalized, err := NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		sr.ToPhoneNumberRaw(phoneNumber)
+		sr.phoneNumberErr = err
+		return sr
+	}
+
+	return sr.ToPhoneNumberRaw(normalized)
+}
+
+// ToPhoneNumberRaw is a fluent helper method for setting Recipient to a phone number exactly
+// as given, for phone numbers that have already been normalized. It is a mutator and returns
+// the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) ToPhoneNumberRaw(phoneNumber string) *SendRequest {
 	sr.Recipient = Recipient{PhoneNumber: phoneNumber}
+	sr.phoneNumberErr = nil
+	return sr
+}
+
+/*
+NormalizePhoneNumber converts a phone number to the E.164 format Facebook requires, such as
+"+15551234567". Spaces, dashes, dots and parentheses are removed. Numbers starting with "+"
+or the "00" international prefix already include a country code, and 10 digit numbers are
+assumed to be US numbers. The result must have between 7 and 15 digits. Any other number,
+such as a national number with a leading 0, returns an error because its country can't be known.
+*/
+func NormalizePhoneNumber(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	international := strings.HasPrefix(trimmed, "+")
+	if international {
+		trimmed = trimmed[1:]
+	}
+
+	digits := make([]rune, 0, len(trimmed))
+	for _, r := range trimmed {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, r)
+		case strings.ContainsRune(" -.()", r):
+		default:
+			return "", fmt.Errorf("phone number %q contains %q, which is not a digit or separator", raw, r)
+		}
+	}
+
+	number := string(digits)
+
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case len(number) == 10 && number[0] != '0' && number[0] != '1':
+		number = "1" + number
+	case len(number) == 11 && number[0] == '1':
+	default:
+		return "", fmt.Errorf("phone number %q has no country code", raw)
+	}
+
+	if len(number) < 7 || len(number) > 15 || number[0] == '0' {
+		return "", fmt.Errorf("phone number %q is not a valid international phone number", raw)
+	}
+
+	return "+" + number, nil
+}
+
+/*
+ToPhoneNumberWithName is a fluent helper method for setting Recipient to a phone number along
+with the name of the user, which Facebook uses to help match the phone number to a user. The
+phone number is converted to E.164 format as by ToPhoneNumber, and Validate returns the error
+when it can't be converted. It is a mutator and returns the same SendRequest on which it is
+called to support method chaining.
+*/
+func (sr *SendRequest) ToPhoneNumberWithName(phoneNumber, firstName, lastName string) *SendRequest {
+	normalized, err := NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		sr.ToPhoneNumberWithNameRaw(phoneNumber, firstName, lastName)
+		sr.phoneNumberErr = err
+		return sr
+	}
+
+	return sr.ToPhoneNumberWithNameRaw(normalized, firstName, lastName)
+}
+
+// ToPhoneNumberWithNameRaw is like ToPhoneNumberWithName, but uses the phone number exactly
+// as given, for phone numbers that have already been normalized. It is a mutator and returns
+// the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) ToPhoneNumberWithNameRaw(phoneNumber, firstName, lastName string) *SendRequest {
+	sr.Recipient = Recipient{
+		PhoneNumber: phoneNumber,
+		Name: &RecipientName{
+			FirstName: firstName,
+			LastName:  lastName,
+		},
+	}
+	sr.phoneNumberErr = nil
+
 	return sr
 }
 
@@ -231,6 +599,63 @@ func (sr *SendRequest) NoPush() *SendRequest {
 	return sr
 }
 
+// WithMessagingType is a fluent helper method for setting MessagingType. It is a mutator and
+// returns the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) WithMessagingType(messagingType string) *SendRequest {
+	sr.MessagingType = messagingType
+
+	return sr
+}
+
+// WithTag is a fluent helper method for setting Tag, which also sets MessagingType to
+// "MESSAGE_TAG" as Facebook requires. It is a mutator and returns the same SendRequest
+// on which it is called to support method chaining.
+func (sr *SendRequest) WithTag(tag string) *SendRequest {
+	sr.MessagingType = MessagingTypeMessageTag
+	sr.Tag = tag
+
+	return sr
+}
+
+// WithPersona is a fluent helper method for setting PersonaId. It is a mutator and
+// returns the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) WithPersona(personaId string) *SendRequest {
+	sr.PersonaId = personaId
+
+	return sr
+}
+
+// TypingBefore is a fluent helper method for setting TypingDelay. It is a mutator and
+// returns the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) TypingBefore(delay time.Duration) *SendRequest {
+	sr.TypingDelay = delay
+
+	return sr
+}
+
+// Send is a fluent helper method for sending the request with client, using
+// Client.SendWithTyping when TypingDelay is set and Client.Send otherwise.
+func (sr *SendRequest) Send(client *Client, pageAccessToken string) (*SendResponse, error) {
+	return sr.SendWithContext(context.Background(), client, pageAccessToken)
+}
+
+// SendWithContext is like Send but allows you to timeout or cancel the request using context.Context.
+func (sr *SendRequest) SendWithContext(ctx context.Context, client *Client, pageAccessToken string) (*SendResponse, error) {
+	if sr.TypingDelay > 0 {
+		return client.SendWithTypingWithContext(ctx, sr, sr.TypingDelay, pageAccessToken)
+	}
+
+	return client.SendWithContext(ctx, sr, pageAccessToken)
+}
+
+// WithMetadata is a fluent helper method for setting the Metadata of the message. It is a
+// mutator and returns the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) WithMetadata(metadata string) *SendRequest {
+	sr.Message.Metadata = metadata
+
+	return sr
+}
+
 // TextReply is a fluent helper method for creating a QuickReply with content type "text".
 func TextReply(title, payload string) *QuickReply {
 	return &QuickReply{
@@ -258,10 +683,25 @@ func LocationReply() *QuickReply {
 	}
 }
 
-// WithQuickReplies is a fluent helper method for setting the quick replies to
-// a message. It is not additive, it replaces any existing quick replies.
+// WithCorrelationId is a fluent helper method for setting CorrelationId. It is a mutator and
+// returns the same SendRequest on which it is called to support method chaining.
+func (sr *SendRequest) WithCorrelationId(correlationId string) *SendRequest {
+	sr.CorrelationId = correlationId
+
+	return sr
+}
+
+// WithQuickReplies is a fluent helper method for adding quick replies to a message.
+// It is additive, so it can be called more than once to build up the quick replies.
 func (sr *SendRequest) WithQuickReplies(replies ...*QuickReply) *SendRequest {
-	sr.Message.QuickReplies = replies
+	sr.Message.QuickReplies = append(sr.Message.QuickReplies, replies...)
+
+	return sr
+}
+
+// ClearQuickReplies is a fluent helper method for removing all quick replies from a message.
+func (sr *SendRequest) ClearQuickReplies() *SendRequest {
+	sr.Message.QuickReplies = nil
 
 	return sr
 }
@@ -272,82 +712,378 @@ SendRequest is the top level structure for representing any type of message to s
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference#request
 */
 type SendRequest struct {
+	MessagingType    string    `json:"messaging_type,omitempty"`
 	Recipient        Recipient `json:"recipient" binding:"required"`
 	Message          Message   `json:"message" binding:"required"`
 	NotificationType string    `json:"notification_type,omitempty"`
-}
+	Tag              string    `json:"tag,omitempty"`
+	PersonaId        string    `json:"persona_id,omitempty"`
 
-// Recipient identifies the user to send to. Either Id or PhoneNumber must be set, but not both.
-type Recipient struct {
-	Id          string `json:"id,omitempty"`
-	PhoneNumber string `json:"phone_number,omitempty"`
+	// CorrelationId is never sent to Facebook. It is copied to the SendResponse so the
+	// response can be associated with the request that produced it.
+	CorrelationId string `json:"-"`
+
+	// TypingDelay is never sent to Facebook. When it is set, SendRequest.Send shows a typing
+	// indicator for the delay before sending the message.
+	TypingDelay time.Duration `json:"-"`
+
+	// phoneNumberErr is the error normalizing the phone number given to ToPhoneNumber, which
+	// Validate returns.
+	phoneNumberErr error
 }
 
-// Message can represent either a text message, or a message with an attachment. Either
-// Text or Attachment must be set, but not both.
-type Message struct {
-	Text         string        `json:"text,omitempty"`
-	Attachment   *Attachment   `json:"attachment,omitempty"`
-	QuickReplies []*QuickReply `json:"quick_replies,omitempty"`
+// Equal reports whether the request sends the same message to the same recipient as other,
+// comparing every field, including the attachment payload, except CorrelationId and
+// TypingDelay, which are never sent to Facebook.
+func (sr *SendRequest) Equal(other *SendRequest) bool {
+	if sr == nil || other == nil {
+		return sr == other
+	}
+
+	a, b := *sr, *other
+	a.CorrelationId, b.CorrelationId = "", ""
+	a.TypingDelay, b.TypingDelay = 0, 0
+	a.phoneNumberErr, b.phoneNumberErr = nil, nil
+
+	return reflect.DeepEqual(a, b)
 }
 
-// Attachment is used to build a message with attached media, or a structured message.
-type Attachment struct {
-	Type    string      `json:"type" binding:"required"`
-	Payload interface{} `json:"payload" binding:"required"`
+// ToJSON returns the JSON the request is sent as, for example to print it when debugging.
+// The data of an uploaded attachment is not included.
+func (sr *SendRequest) ToJSON() ([]byte, error) {
+	return json.Marshal(sr)
 }
 
 /*
-ResourcePayload is used to hold the URL of a resource (image, file, etc.) to attach to a message.
-
-See https://developers.facebook.com/docs/messenger-platform/send-api-reference/image-attachment
+Hash returns the hex encoded SHA-256 digest of the JSON the request is sent as, for use as a
+map key when detecting duplicate sends. Requests that are Equal have the same hash, however
+they were built. The data of an uploaded attachment is included in the digest, even though
+it is not part of the JSON.
 */
-type ResourcePayload struct {
-	URL string `json:"url" binding:"required"`
-}
+func (sr *SendRequest) Hash() string {
+	hash := sha256.New()
 
-/*
-DataPayload is used to hold the bytes of a resource (image, file, etc.) to upload and attach
-to a message. All fields are required. FileName will only be visible to the recipient when
-type of the attachment is "file".
+	requestBytes, _ := json.Marshal(sr)
+	hash.Write(requestBytes)
 
-See https://developers.facebook.com/docs/messenger-platform/send-api-reference/image-attachment
-*/
-type DataPayload struct {
-	Data        []byte `json:"-"`
-	ContentType string `json:"-"`
-	FileName    string `json:"-"`
+	if sr.Message.Attachment != nil {
+		if data, ok := sr.Message.Attachment.Payload.(DataPayload); ok {
+			fmt.Fprintf(hash, "\x00%v\x00%v\x00", data.ContentType, data.FileName)
+			hash.Write(data.Data)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
-/*
-ButtonPayload is used to build a structured message using the button template.
+// Values for the MessagingType of a SendRequest.
+//
+// See https://developers.facebook.com/docs/messenger-platform/send-messages#messaging_types
+const (
+	MessagingTypeResponse   = "RESPONSE"
+	MessagingTypeUpdate     = "UPDATE"
+	MessagingTypeMessageTag = "MESSAGE_TAG"
+)
 
-See https://developers.facebook.com/docs/messenger-platform/send-api-reference/button-template
+// Values for the Tag of a SendRequest, which allow sending messages outside the
+// standard messaging window.
+//
+// See https://developers.facebook.com/docs/messenger-platform/send-messages/message-tags
+const (
+	TagConfirmedEventUpdate = "CONFIRMED_EVENT_UPDATE"
+	TagPostPurchaseUpdate   = "POST_PURCHASE_UPDATE"
+	TagAccountUpdate        = "ACCOUNT_UPDATE"
+	TagHumanAgent           = "HUMAN_AGENT"
+)
+
+// Recipient identifies the user to send to. Either Id or PhoneNumber must be set, but not both.
+// Name may be set along with PhoneNumber to help Facebook match the phone number to a user.
+// OneTimeNotifToken is used instead to send a one-time notification the user agreed to, and
+// UserRef to send to a user who opted in with the checkbox plugin.
+type Recipient struct {
+	Id                string         `json:"id,omitempty"`
+	PhoneNumber       string         `json:"phone_number,omitempty"`
+	Name              *RecipientName `json:"name,omitempty"`
+	OneTimeNotifToken string         `json:"one_time_notif_token,omitempty"`
+	UserRef           string         `json:"user_ref,omitempty"`
+}
+
+/*
+String describes the recipient for display and logging, without leaking personal details:
+"user:" and the Id, "phone:" and the phone number with all but its last 4 digits masked,
+or "user_ref:" and the first 8 characters of the user ref. The token of a one-time
+notification is truncated the same way.
+*/
+func (r Recipient) String() string {
+	switch {
+	case r.Id != "":
+		return "user:" + r.Id
+	case r.PhoneNumber != "":
+		return "phone:" + maskPhoneNumber(r.PhoneNumber)
+	case r.UserRef != "":
+		return "user_ref:" + truncateForDisplay(r.UserRef)
+	case r.OneTimeNotifToken != "":
+		return "one_time_notif_token:" + truncateForDisplay(r.OneTimeNotifToken)
+	default:
+		return ""
+	}
+}
+
+func maskPhoneNumber(phoneNumber string) string {
+	const shown = 4
+
+	runes := []rune(phoneNumber)
+	for i := 0; i < len(runes)-shown; i++ {
+		if runes[i] >= '0' && runes[i] <= '9' {
+			runes[i] = '*'
+		}
+	}
+
+	return string(runes)
+}
+
+func truncateForDisplay(s string) string {
+	const shown = 8
+
+	runes := []rune(s)
+	if len(runes) <= shown {
+		return s
+	}
+
+	return string(runes[:shown]) + "..."
+}
+
+// RecipientName holds the name of a user being sent a message by phone number.
+type RecipientName struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Message can represent either a text message, or a message with an attachment. Either
+// Text or Attachment must be set, but not both.
+type Message struct {
+	Text         string        `json:"text,omitempty"`
+	Attachment   *Attachment   `json:"attachment,omitempty"`
+	QuickReplies []*QuickReply `json:"quick_replies,omitempty"`
+	Metadata     string        `json:"metadata,omitempty"`
+}
+
+// ToJSON returns the JSON the message is sent as.
+func (m *Message) ToJSON() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// NewTextMessageBody creates a text Message on its own, such as for a message creative. An
+// empty Message is returned with an error when the text is longer than MaxTextLength characters.
+func NewTextMessageBody(text string) (Message, error) {
+	if err := validateLength("Message.Text", text, MaxTextLength); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Text: text}, nil
+}
+
+// NewAttachmentMessageBody creates a Message with an attachment on its own, such as for a
+// message creative.
+func NewAttachmentMessageBody(attachment *Attachment) Message {
+	return Message{Attachment: attachment}
+}
+
+// NewQuickReplyMessageBody is like NewTextMessageBody but with quick replies added to the Message.
+func NewQuickReplyMessageBody(text string, quickReplies []*QuickReply) (Message, error) {
+	message, err := NewTextMessageBody(text)
+	if err != nil {
+		return Message{}, err
+	}
+
+	message.QuickReplies = quickReplies
+
+	return message, nil
+}
+
+// Attachment is used to build a message with attached media, or a structured message.
+type Attachment struct {
+	Type    string      `json:"type" binding:"required"`
+	Payload interface{} `json:"payload" binding:"required"`
+}
+
+// ToJSON returns the JSON the attachment is sent as.
+func (a *Attachment) ToJSON() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// ImageAttachment creates an Attachment of an image using the URL of the image.
+func ImageAttachment(url string) *Attachment {
+	return &Attachment{Type: "image", Payload: ResourcePayload{URL: url}}
+}
+
+// AudioAttachment creates an Attachment of an audio file using the URL of the file.
+func AudioAttachment(url string) *Attachment {
+	return &Attachment{Type: "audio", Payload: ResourcePayload{URL: url}}
+}
+
+// VideoAttachment creates an Attachment of a video using the URL of the video.
+func VideoAttachment(url string) *Attachment {
+	return &Attachment{Type: "video", Payload: ResourcePayload{URL: url}}
+}
+
+// FileAttachment creates an Attachment of a file using the URL of the file.
+func FileAttachment(url string) *Attachment {
+	return &Attachment{Type: "file", Payload: ResourcePayload{URL: url}}
+}
+
+// ReusableAttachment creates an Attachment of a resource previously uploaded with IsReusable
+// set, such as "image", using the attachment id Facebook returned for it. Facebook requires
+// the type of the resource to be given with the id.
+func ReusableAttachment(attachmentType, attachmentId string) *Attachment {
+	return &Attachment{Type: attachmentType, Payload: ResourcePayload{AttachmentId: attachmentId}}
+}
+
+// TemplateAttachment creates an Attachment of a structured message built from a template
+// payload. See TemplateMessage.
+func TemplateAttachment(payload TemplateTyper) *Attachment {
+	return &Attachment{Type: "template", Payload: payload}
+}
+
+// TemplateType identifies the template used by a structured message.
+type TemplateType string
+
+const (
+	TemplateTypeButton              TemplateType = "button"
+	TemplateTypeGeneric             TemplateType = "generic"
+	TemplateTypeList                TemplateType = "list"
+	TemplateTypeReceipt             TemplateType = "receipt"
+	TemplateTypeMedia               TemplateType = "media"
+	TemplateTypeOpenGraph           TemplateType = "open_graph"
+	TemplateTypeAirlineItinerary    TemplateType = "airline_itinerary"
+	TemplateTypeAirlineBoardingPass TemplateType = "airline_boardingpass"
+	TemplateTypeAirlineCheckin      TemplateType = "airline_checkin"
+	TemplateTypeAirlineFlightUpdate TemplateType = "airline_update"
+	TemplateTypeOneTimeNotif        TemplateType = "one_time_notif_req"
+	TemplateTypeCustomerFeedback    TemplateType = "customer_feedback"
+)
+
+// TemplateTyper is implemented by template payloads to report the template_type they are
+// sent with. See TemplateMessage.
+type TemplateTyper interface {
+	GetTemplateType() string
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p ButtonPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p GenericPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p ListPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p MediaPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p OneTimeNotificationRequestPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p ReceiptPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+// GetTemplateType implements TemplateTyper.
+func (p CustomerFeedbackPayload) GetTemplateType() string {
+	return string(p.TemplateType)
+}
+
+/*
+ResourcePayload is used to hold the URL of a resource (image, file, etc.) to attach to a message,
+or the AttachmentId of a resource previously uploaded with IsReusable set.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/image-attachment
+*/
+type ResourcePayload struct {
+	URL              string           `json:"url,omitempty"`
+	AttachmentId     string           `json:"attachment_id,omitempty"`
+	IsReusable       bool             `json:"is_reusable,omitempty"`
+	ImageAspectRatio ImageAspectRatio `json:"image_aspect_ratio,omitempty"`
+}
+
+/*
+DataPayload is used to hold the bytes of a resource (image, file, etc.) to upload and attach
+to a message. All fields are required. FileName will only be visible to the recipient when
+type of the attachment is "file".
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/image-attachment
+*/
+type DataPayload struct {
+	Data        []byte `json:"-"`
+	ContentType string `json:"-"`
+	FileName    string `json:"-"`
+}
+
+/*
+ButtonPayload is used to build a structured message using the button template.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/button-template
 */
 type ButtonPayload struct {
-	TemplateType string    `json:"template_type" binding:"required"`
-	Text         string    `json:"text" binding:"required"`
-	Buttons      []*Button `json:"buttons" binding:"required"`
+	TemplateType TemplateType `json:"template_type" binding:"required"`
+	Text         string       `json:"text" binding:"required"`
+	Buttons      []*Button    `json:"buttons" binding:"required"`
 }
 
 // Button represents a single button in a structured message using the button template.
 type Button struct {
 	Type    string `json:"type" binding:"required"`
-	Title   string `json:"title" binding:"required"`
+	Title   string `json:"title,omitempty"`
 	URL     string `json:"url,omitempty"`
 	Payload string `json:"payload,omitempty"`
 }
 
+// ToJSON returns the JSON the button is sent as.
+func (b *Button) ToJSON() ([]byte, error) {
+	return json.Marshal(b)
+}
+
 /*
 GenericPayload is used to build a structured message using the generic template.
 
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference/generic-template
 */
 type GenericPayload struct {
-	TemplateType string            `json:"template_type" binding:"required"`
-	Elements     []*GenericElement `json:"elements" binding:"required"`
+	TemplateType     TemplateType      `json:"template_type" binding:"required"`
+	ImageAspectRatio ImageAspectRatio  `json:"image_aspect_ratio,omitempty"`
+	Elements         []*GenericElement `json:"elements" binding:"required"`
+}
+
+/*
+WithImageAspectRatio is a fluent helper method for setting the aspect ratio of the images
+in the elements of a generic template. It is a mutator and returns the same GenericPayload
+on which it is called to support method chaining.
+*/
+func (p *GenericPayload) WithImageAspectRatio(r ImageAspectRatio) *GenericPayload {
+	p.ImageAspectRatio = r
+
+	return p
 }
 
+// ImageAspectRatio sets how the images of a generic template message are rendered.
+type ImageAspectRatio string
+
+const (
+	ImageAspectRatioHorizontal ImageAspectRatio = "horizontal" // 1.91:1, the default
+	ImageAspectRatioSquare     ImageAspectRatio = "square"     // 1:1
+)
+
 // GenericElement represents one item in the carousel of a generic template message.
 type GenericElement struct {
 	Title    string    `json:"title" binding:"required"`
@@ -356,18 +1092,122 @@ type GenericElement struct {
 	Buttons  []*Button `json:"buttons" binding:"required"`
 }
 
+/*
+ListPayload is used to build a structured message using the list template.
+
+See https://developers.facebook.com/docs/messenger-platform/send-api-reference/list-template
+*/
+type ListPayload struct {
+	TemplateType    TemplateType      `json:"template_type" binding:"required"`
+	TopElementStyle ListTemplateStyle `json:"top_element_style,omitempty"`
+	Elements        []*ListElement    `json:"elements" binding:"required"`
+	Buttons         []*Button         `json:"buttons,omitempty"`
+}
+
+// ListTemplateStyle sets how the first element of a list template message is rendered.
+type ListTemplateStyle string
+
+const (
+	ListStyleLarge   ListTemplateStyle = "large"
+	ListStyleCompact ListTemplateStyle = "compact"
+)
+
+// ListElement represents one item in a list template message.
+type ListElement struct {
+	Title    string    `json:"title" binding:"required"`
+	Subtitle string    `json:"subtitle,omitempty"`
+	ImageURL string    `json:"image_url,omitempty"`
+	Buttons  []*Button `json:"buttons,omitempty"`
+}
+
+/*
+MediaPayload is used to build a structured message using the media template.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/template/media
+*/
+type MediaPayload struct {
+	TemplateType TemplateType    `json:"template_type" binding:"required"`
+	Elements     []*MediaElement `json:"elements" binding:"required"`
+}
+
+// MediaElement represents the image or video of a media template message. Either URL
+// or AttachmentId must be set, but not both.
+type MediaElement struct {
+	MediaType    string    `json:"media_type" binding:"required"`
+	URL          string    `json:"url,omitempty"`
+	AttachmentId string    `json:"attachment_id,omitempty"`
+	Buttons      []*Button `json:"buttons,omitempty"`
+}
+
+// OneTimeNotificationRequestPayload is used to build a structured message requesting
+// permission to send a one-time notification.
+type OneTimeNotificationRequestPayload struct {
+	TemplateType TemplateType `json:"template_type" binding:"required"`
+	Title        string       `json:"title" binding:"required"`
+	Payload      string       `json:"payload" binding:"required"`
+}
+
+/*
+CustomerFeedbackPayload is used to build a structured message using the customer feedback
+template. ExpiresInDays is how long the user can answer, from 1 to 7 days.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/templates/customer-feedback-template
+*/
+type CustomerFeedbackPayload struct {
+	TemplateType    TemplateType      `json:"template_type" binding:"required"`
+	Title           string            `json:"title" binding:"required"`
+	Subtitle        string            `json:"subtitle" binding:"required"`
+	ButtonTitle     string            `json:"button_title" binding:"required"`
+	FeedbackScreens []*FeedbackScreen `json:"feedback_screens" binding:"required"`
+	BusinessPrivacy *BusinessPrivacy  `json:"business_privacy" binding:"required"`
+	ExpiresInDays   int               `json:"expires_in_days,omitempty"`
+}
+
+// FeedbackScreen is one screen of questions in a customer feedback template message.
+type FeedbackScreen struct {
+	Questions []*FeedbackQuestion `json:"questions" binding:"required"`
+}
+
+/*
+FeedbackQuestion is a question in a customer feedback template message. Type is the kind of
+score, such as "csat" or "nps", ScoreLabel the labels shown with it, such as "neg_pos", and
+ScoreOption the options to choose from, such as "five_stars". The Id is used to match answers
+to questions in the FeedbackResponse.
+*/
+type FeedbackQuestion struct {
+	Id          string            `json:"id" binding:"required"`
+	Type        string            `json:"type" binding:"required"`
+	Title       string            `json:"title,omitempty"`
+	ScoreLabel  string            `json:"score_label,omitempty"`
+	ScoreOption string            `json:"score_option,omitempty"`
+	FollowUp    *FeedbackFollowUp `json:"follow_up,omitempty"`
+}
+
+// FeedbackFollowUp is a free form follow up to a question in a customer feedback template message.
+type FeedbackFollowUp struct {
+	Type        string `json:"type" binding:"required"`
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+// BusinessPrivacy holds the URL of the privacy policy of the business asking for feedback.
+type BusinessPrivacy struct {
+	URL string `json:"url" binding:"required"`
+}
+
 /*
 ReceiptPayload is used to build a structured message using the receipt template.
+Facebook renders OrderURL as a link to the order and Timestamp, in seconds since the
+Unix epoch, as the order date.
 
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference/receipt-template
 */
 type ReceiptPayload struct {
-	TemplateType  string               `json:"template_type" binding:"required"`
+	TemplateType  TemplateType         `json:"template_type" binding:"required"`
 	RecipientName string               `json:"recipient_name" binding:"required"`
 	OrderNumber   string               `json:"order_number" binding:"required"`
 	Currency      string               `json:"currency" binding:"required"`
 	PaymentMethod string               `json:"payment_method" binding:"required"`
-	Timestamp     string               `json:"timestamp,omitempty"`
+	Timestamp     int64                `json:"timestamp,string,omitempty"`
 	OrderURL      string               `json:"order_url,omitempty"`
 	Elements      []*ReceiptElement    `json:"elements" binding:"required"`
 	Address       *Address             `json:"address,omitempty"`
@@ -411,6 +1251,72 @@ type ReceiptAdjustment struct {
 	Amount json.Number `json:"amount"`
 }
 
+/*
+FlightInfo describes a flight in the airline itinerary, boarding pass and check-in templates.
+ConnectionId and SegmentId link the flight to the passenger segment info of an itinerary.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/template/airline-itinerary
+*/
+type FlightInfo struct {
+	ConnectionId     string         `json:"connection_id,omitempty"`
+	SegmentId        string         `json:"segment_id,omitempty"`
+	FlightNumber     string         `json:"flight_number" binding:"required"`
+	AircraftType     string         `json:"aircraft_type,omitempty"`
+	DepartureAirport AirportInfo    `json:"departure_airport" binding:"required"`
+	ArrivalAirport   AirportInfo    `json:"arrival_airport" binding:"required"`
+	FlightSchedule   FlightSchedule `json:"flight_schedule" binding:"required"`
+	TravelClass      string         `json:"travel_class,omitempty"`
+}
+
+// AirportInfo identifies the airport a flight departs from or arrives at.
+type AirportInfo struct {
+	AirportCode string `json:"airport_code" binding:"required"`
+	City        string `json:"city" binding:"required"`
+	Terminal    string `json:"terminal,omitempty"`
+	Gate        string `json:"gate,omitempty"`
+}
+
+// FlightSchedule holds the times of a flight. Facebook expects the times as strings in
+// ISO 8601 format, such as "2016-01-02T19:05".
+type FlightSchedule struct {
+	BoardingTime  string `json:"boarding_time,omitempty"`
+	DepartureTime string `json:"departure_time" binding:"required"`
+	ArrivalTime   string `json:"arrival_time,omitempty"`
+}
+
+// PassengerInfo identifies a passenger in the airline itinerary template.
+type PassengerInfo struct {
+	PassengerId  string `json:"passenger_id" binding:"required"`
+	TicketNumber string `json:"ticket_number,omitempty"`
+	Name         string `json:"name" binding:"required"`
+}
+
+// PassengerSegmentInfo holds the seat and other details for one passenger on one flight
+// segment of an airline itinerary.
+type PassengerSegmentInfo struct {
+	SegmentId   string         `json:"segment_id" binding:"required"`
+	PassengerId string         `json:"passenger_id" binding:"required"`
+	Seat        string         `json:"seat" binding:"required"`
+	SeatType    string         `json:"seat_type" binding:"required"`
+	ProductInfo []*ProductInfo `json:"product_info,omitempty"`
+}
+
+// ProductInfo is a title and value pair describing an extra, such as a meal or lounge
+// access, on a passenger segment.
+type ProductInfo struct {
+	Title string `json:"title" binding:"required"`
+	Value string `json:"value" binding:"required"`
+}
+
+// PriceInfo represents an additional line item, such as fuel surcharge, in the price of
+// an airline itinerary.
+type PriceInfo struct {
+	Title    string      `json:"title" binding:"required"`
+	Amount   json.Number `json:"amount" binding:"required"`
+	Currency string      `json:"currency,omitempty"`
+	Type     string      `json:"type,omitempty"`
+}
+
 // QuickReply represents a quick reply to a message.
 type QuickReply struct {
 	ContentType string `json:"content_type" binding:"required"`
@@ -419,19 +1325,60 @@ type QuickReply struct {
 	ImageURL    string `json:"image_url,omitempty"`
 }
 
+/*
+SenderActionRequest sets the typing indicator or marks the last message as seen in the
+conversation with a user. SenderAction is one of the SenderAction* constants.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/sender-actions
+*/
+type SenderActionRequest struct {
+	Recipient    Recipient    `json:"recipient" binding:"required"`
+	SenderAction SenderAction `json:"sender_action" binding:"required"`
+}
+
+// SenderAction is the action taken by a SenderActionRequest.
+type SenderAction string
+
+const (
+	SenderActionTypingOn  SenderAction = "typing_on"
+	SenderActionTypingOff SenderAction = "typing_off"
+	SenderActionMarkSeen  SenderAction = "mark_seen"
+)
+
+// NewTypingOnRequest creates a request that turns the typing indicator on for the user.
+func NewTypingOnRequest(userId string) *SenderActionRequest {
+	return &SenderActionRequest{Recipient: Recipient{Id: userId}, SenderAction: SenderActionTypingOn}
+}
+
+// NewTypingOffRequest creates a request that turns the typing indicator off for the user.
+func NewTypingOffRequest(userId string) *SenderActionRequest {
+	return &SenderActionRequest{Recipient: Recipient{Id: userId}, SenderAction: SenderActionTypingOff}
+}
+
+// NewMarkSeenRequest creates a request that marks the last message from the user as seen.
+func NewMarkSeenRequest(userId string) *SenderActionRequest {
+	return &SenderActionRequest{Recipient: Recipient{Id: userId}, SenderAction: SenderActionMarkSeen}
+}
+
 /*
 SendResponse is returned when sending a SendRequest.
 
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference#response
 */
 type SendResponse struct {
-	RecipientId string     `json:"recipient_id" binding:"required"`
-	MessageId   string     `json:"message_id" binding:"required"`
-	Error       *SendError `json:"error"`
+	RecipientId  string     `json:"recipient_id" binding:"required"`
+	MessageId    string     `json:"message_id" binding:"required"`
+	AttachmentId string     `json:"attachment_id,omitempty"`
+	Error        *SendError `json:"error"`
+
+	// CorrelationId is copied from the SendRequest that produced the response.
+	CorrelationId string `json:"-"`
 }
 
 /*
-SendError indicates an error returned from Facebook.
+SendError indicates an error returned from Facebook. It is returned as an error by the
+Client methods. Use errors.As to get the SendError from an error, or errors.Is to check
+the kind of error against the sentinel errors below.
 
 See https://developers.facebook.com/docs/messenger-platform/send-api-reference#errors
 */
@@ -441,16 +1388,133 @@ type SendError struct {
 	Code      int    `json:"code" binding:"required"`
 	ErrorData string `json:"error_data" binding:"required"`
 	FBTraceId string `json:"fbtrace_id" binding:"required"`
+
+	// RetryAfter is set by the Client from the Retry-After header of the response, which
+	// Facebook sends with some rate limiting errors.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// Error implements the error interface, so that errors returned from Facebook can be
+// returned as errors. All of the fields of the error are included.
+func (e *SendError) Error() string {
+	s := fmt.Sprintf("Facebook API error: [code %v] %v: %v", e.Code, e.Type, e.Message)
+
+	if e.ErrorData != "" {
+		s += fmt.Sprintf(" (error_data: %v)", e.ErrorData)
+	}
+
+	if e.FBTraceId != "" {
+		s += fmt.Sprintf(" (fbtrace_id: %v)", e.FBTraceId)
+	}
+
+	return s
+}
+
+// Unwrap returns the sentinel error for the kind of error indicated by the code, or nil
+// when the code is not one of the codes with a sentinel error.
+func (e *SendError) Unwrap() error {
+	switch {
+	case e.Code == 1 || e.Code == 2:
+		return ErrAPIService
+	case e.Code == 4 || e.Code == 17 || e.Code == 32 || e.Code == 613:
+		return ErrRateLimited
+	case e.Code == 100:
+		return ErrInvalidParameter
+	case e.Code == 190:
+		return ErrAccessToken
+	case e.Code == 10 || (e.Code >= 200 && e.Code <= 299):
+		return ErrPermission
+	case e.Code == 551:
+		return ErrUserUnavailable
+	case e.Code == 1200:
+		return ErrTemporarySendFailure
+	}
+
+	return nil
+}
+
+// IsRetryAfterError returns how long to wait before retrying when err is a SendError with
+// RetryAfter set, and false otherwise.
+func IsRetryAfterError(err error) (time.Duration, bool) {
+	var sendError *SendError
+	if !errors.As(err, &sendError) || sendError.RetryAfter <= 0 {
+		return 0, false
+	}
+
+	return sendError.RetryAfter, true
 }
 
+// The sentinel errors wrapped by SendError, by the kind of error indicated by the code.
+var (
+	ErrAPIService           = errors.New("facebook API service error")
+	ErrRateLimited          = errors.New("facebook rate limit reached")
+	ErrInvalidParameter     = errors.New("facebook invalid parameter")
+	ErrAccessToken          = errors.New("facebook access token error")
+	ErrPermission           = errors.New("facebook permission error")
+	ErrUserUnavailable      = errors.New("facebook user unavailable")
+	ErrTemporarySendFailure = errors.New("facebook temporary send failure")
+)
+
 /*------------------------------------------------------
 Webhook
 ------------------------------------------------------*/
 
+// ParseTimestamp converts a Facebook timestamp, in milliseconds since the Unix epoch,
+// to a time.Time in UTC. The zero time.Time is returned for a timestamp of zero.
+func ParseTimestamp(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+// FormatTimestamp converts a time.Time to a Facebook timestamp in milliseconds since the
+// Unix epoch. It is the inverse of ParseTimestamp, so the zero time.Time yields zero.
+func FormatTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+/*
+Platform identifies which of Meta's messaging products a callback was received from. The
+Messenger and Instagram webhooks share the same format, so the same types are used for both.
+*/
+type Platform string
+
+const (
+	PlatformMessenger Platform = "messenger"
+	PlatformInstagram Platform = "instagram"
+	PlatformWhatsApp  Platform = "whatsapp"
+)
+
+// PlatformFromObject returns the platform for the object field of a callback, or an empty
+// Platform if the object is not recognized.
+func PlatformFromObject(object string) Platform {
+	switch object {
+	case "page":
+		return PlatformMessenger
+	case "instagram":
+		return PlatformInstagram
+	case "whatsapp_business_account":
+		return PlatformWhatsApp
+	}
+
+	return ""
+}
+
 /*
 Callback is the top level structure that represents a callback received by your
 webhook endpoint.
 
+Facebook batches events, so a single callback may contain several entries, and when your
+app is subscribed to more than one page those entries may be for different pages. Use
+FlattenMessaging, or CallbackDispatcher, to process every messaging entry along with the
+page it belongs to.
+
 See https://developers.facebook.com/docs/messenger-platform/webhook-reference#format
 */
 type Callback struct {
@@ -458,64 +1522,556 @@ type Callback struct {
 	Entries []*Entry `json:"entry" binding:"required"`
 }
 
-// Entry is part of the common format of callbacks.
-type Entry struct {
-	PageId    string            `json:"id" binding:"required"`
-	Time      int               `json:"time" binding:"required"`
-	Messaging []*MessagingEntry `json:"messaging"`
-}
-
 /*
-MessagingEntry is an individual interaction a user has with a page.
-The Sender and Recipient fields are common to all types of callbacks and the
-other fields only apply to specific types of callbacks.
+UnmarshalJSON decodes a callback and records the platform it was received from on each of
+its entries, so that Entry.Platform can be used when entries are handled on their own. Each
+messaging entry is also attributed to the page of the entry containing it, which is then
+available from MessagingEntry.PageID.
 */
-type MessagingEntry struct {
-	Sender    Principal        `json:"sender" binding:"required"`
-	Recipient Principal        `json:"recipient" binding:"required"`
-	Timestamp int              `json:"timestamp"`
-	Message   *CallbackMessage `json:"message"`
-	Delivery  *Delivery        `json:"delivery"`
-	Postback  *Postback        `json:"postback"`
-	OptIn     *OptIn           `json:"optin"`
-}
+func (cb *Callback) UnmarshalJSON(data []byte) error {
+	type callback Callback
 
-// Principal holds the Id of a sender or recipient.
-type Principal struct {
-	Id string `json:"id" binding:"required"`
-}
+	var decoded callback
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
 
-/*
-CallbackMessage represents a message a user has sent to your page.
-Either the Text or Attachments field will be set, but not both.
+	*cb = Callback(decoded)
 
-See https://developers.facebook.com/docs/messenger-platform/webhook-reference/message-received
-*/
-type CallbackMessage struct {
-	MessageId   string                `json:"mid" binding:"required"`
-	Sequence    int                   `json:"seq" binding:"required"`
-	Text        string                `json:"text"`
-	Attachments []*CallbackAttachment `json:"attachments"`
-	QuickReply  *CallbackQuickReply   `json:"quick_reply"`
+	platform := cb.Platform()
+	for _, entry := range cb.Entries {
+		if entry == nil {
+			continue
+		}
+
+		entry.platform = platform
+		for _, messagingEntry := range entry.Messaging {
+			if messagingEntry != nil {
+				messagingEntry.pageId = entry.PageId
+			}
+		}
+	}
+
+	return nil
 }
 
-// CallbackAttachment holds the type and payload of an attachment sent by a user.
-type CallbackAttachment struct {
-	Title   string                    `json:"title"`
-	URL     string                    `json:"url"`
-	Type    string                    `json:"type" binding:"required"`
-	Payload CallbackAttachmentPayload `json:"payload" binding:"required"`
+// Platform returns the platform the callback was received from, based on its Object.
+func (cb *Callback) Platform() Platform {
+	return PlatformFromObject(cb.Object)
 }
 
-// CallbackAttachmentPayload holds the URL of a multimedia attachment,
-// or the coordinates of a location attachment sent by the user.
-type CallbackAttachmentPayload struct {
-	URL         string       `json:"url"`
-	Coordinates *Coordinates `json:"coordinates"`
+// EntryCount returns the number of entries in the callback.
+func (cb *Callback) EntryCount() int {
+	return len(cb.Entries)
 }
 
-// Coordinates holds the latitude and longitude of a location.
-type Coordinates struct {
+// PageIDs returns the Ids of the pages the entries in the callback are for, without
+// duplicates, in the order they first appear.
+func (cb *Callback) PageIDs() []string {
+	var pageIds []string
+	seen := map[string]bool{}
+
+	for _, entry := range cb.Entries {
+		if entry != nil && !seen[entry.PageId] {
+			seen[entry.PageId] = true
+			pageIds = append(pageIds, entry.PageId)
+		}
+	}
+
+	return pageIds
+}
+
+// PageID returns the Id of the page the entries in the callback are for, and whether they
+// are all for that one page. False is returned when the entries are for more than one page,
+// or there are no entries.
+func (cb *Callback) PageID() (string, bool) {
+	pageIds := cb.PageIDs()
+	if len(pageIds) != 1 {
+		return "", false
+	}
+
+	return pageIds[0], true
+}
+
+// FirstEntry returns the first entry in the callback, and whether there is one.
+func (cb *Callback) FirstEntry() (*Entry, bool) {
+	if len(cb.Entries) == 0 {
+		return nil, false
+	}
+
+	return cb.Entries[0], true
+}
+
+// FirstMessaging returns the first messaging entry of the first entry in the callback, and
+// whether there is one.
+func (cb *Callback) FirstMessaging() (*MessagingEntry, bool) {
+	entry, ok := cb.FirstEntry()
+	if !ok || entry == nil {
+		return nil, false
+	}
+
+	return entry.FirstMessaging()
+}
+
+/*
+FlattenMessaging returns the messaging entries of every entry in the callback as a single
+slice, in the order they were received. Nil entries and messaging entries are skipped. When
+the callback was decoded from JSON, MessagingEntry.PageID returns the page of each one.
+*/
+func (cb *Callback) FlattenMessaging() []*MessagingEntry {
+	var messaging []*MessagingEntry
+
+	for _, entry := range cb.Entries {
+		if entry == nil {
+			continue
+		}
+
+		for _, messagingEntry := range entry.Messaging {
+			if messagingEntry != nil {
+				messaging = append(messaging, messagingEntry)
+			}
+		}
+	}
+
+	return messaging
+}
+
+// ForEach calls fn with each messaging entry in the callback, in the order they were
+// received, along with the Id of the page of the entry containing it.
+func (cb *Callback) ForEach(fn func(pageId string, entry *MessagingEntry)) {
+	for _, entry := range cb.Entries {
+		if entry == nil {
+			continue
+		}
+
+		for _, messagingEntry := range entry.Messaging {
+			if messagingEntry != nil {
+				fn(entry.PageId, messagingEntry)
+			}
+		}
+	}
+}
+
+// ForEachMessage is like ForEach but only calls fn with the messaging entries holding a message.
+func (cb *Callback) ForEachMessage(fn func(pageId string, entry *MessagingEntry)) {
+	cb.ForEach(func(pageId string, entry *MessagingEntry) {
+		if entry.Message != nil {
+			fn(pageId, entry)
+		}
+	})
+}
+
+// Filter is like FlattenMessaging but only returns the messaging entries for which pred
+// returns true.
+func (cb *Callback) Filter(pred func(*MessagingEntry) bool) []*MessagingEntry {
+	var messaging []*MessagingEntry
+
+	for _, messagingEntry := range cb.FlattenMessaging() {
+		if pred(messagingEntry) {
+			messaging = append(messaging, messagingEntry)
+		}
+	}
+
+	return messaging
+}
+
+// Entry is part of the common format of callbacks.
+type Entry struct {
+	PageId    string            `json:"id" binding:"required"`
+	Time      int64             `json:"time" binding:"required"`
+	Messaging []*MessagingEntry `json:"messaging"`
+
+	platform Platform
+}
+
+// Platform returns the platform of the callback the entry was received in. It is empty for
+// entries that were not decoded as part of a Callback.
+func (e *Entry) Platform() Platform {
+	return e.platform
+}
+
+// PageID returns the Id of the page the entry is for.
+func (e *Entry) PageID() string {
+	return e.PageId
+}
+
+// At returns the Time of the entry as a time.Time. The zero time.Time is returned
+// when no time was included in the callback.
+func (e *Entry) At() time.Time {
+	return ParseTimestamp(e.Time)
+}
+
+// HasMessaging reports whether the entry contains any messaging entries.
+func (e *Entry) HasMessaging() bool {
+	return len(e.Messaging) > 0
+}
+
+// FirstMessaging returns the first messaging entry of the entry, and whether there is one.
+func (e *Entry) FirstMessaging() (*MessagingEntry, bool) {
+	if !e.HasMessaging() || e.Messaging[0] == nil {
+		return nil, false
+	}
+
+	return e.Messaging[0], true
+}
+
+/*
+MessagingEntry is an individual interaction a user has with a page.
+The Sender and Recipient fields are common to all types of callbacks and the
+other fields only apply to specific types of callbacks.
+*/
+type MessagingEntry struct {
+	Sender        Principal         `json:"sender" binding:"required"`
+	Recipient     Principal         `json:"recipient" binding:"required"`
+	Timestamp     int               `json:"timestamp"`
+	Message       *CallbackMessage  `json:"message"`
+	Delivery      *Delivery         `json:"delivery"`
+	Read          *Read             `json:"read"`
+	Feedback      *FeedbackResponse `json:"messaging_feedback"`
+	Postback      *Postback         `json:"postback"`
+	OptIn         *OptIn            `json:"optin"`
+	Reaction      *Reaction         `json:"reaction"`
+	MessageDelete *MessageDelete    `json:"message_delete"`
+	Referral      *Referral         `json:"referral"`
+
+	pageId string
+}
+
+// IsReferral reports whether the entry has a referral of its own, which is set when an
+// existing conversation is entered through an m.me link or an ad. Referrals for a new
+// conversation arrive on the Postback of the get started button instead.
+func (e *MessagingEntry) IsReferral() bool {
+	return e.Referral != nil
+}
+
+// ReadEvent returns the Read of the entry, and whether the entry is a read at all.
+func (e *MessagingEntry) ReadEvent() (*Read, bool) {
+	return e.Read, e.Read != nil
+}
+
+// IsReaction reports whether the entry is a user reacting to, or removing a reaction
+// from, a message.
+func (e *MessagingEntry) IsReaction() bool {
+	return e.Reaction != nil
+}
+
+// IsMessageDelete reports whether the entry is a user unsending a message.
+func (e *MessagingEntry) IsMessageDelete() bool {
+	return e.MessageDelete != nil
+}
+
+// PageID returns the Id of the page of the Entry containing this messaging entry. It is set
+// when the Callback is decoded from JSON, and is empty otherwise.
+func (e *MessagingEntry) PageID() string {
+	return e.pageId
+}
+
+// SenderID returns the Id of the sender of the entry.
+func (e *MessagingEntry) SenderID() string {
+	return e.Sender.Id
+}
+
+// RecipientID returns the Id of the recipient of the entry.
+func (e *MessagingEntry) RecipientID() string {
+	return e.Recipient.Id
+}
+
+// At returns the Timestamp of the entry as a time.Time. The zero time.Time is returned
+// when no timestamp was included in the callback.
+func (e *MessagingEntry) At() time.Time {
+	return ParseTimestamp(int64(e.Timestamp))
+}
+
+/*
+ConversationRef identifies the conversation between a page and a user, which stays the same
+as control of the conversation is handed over between apps. ThreadId is the Id of the user.
+*/
+type ConversationRef struct {
+	ThreadId string
+	PageId   string
+}
+
+// ConversationKey returns "pageId:userId", for use as a map key.
+func (r ConversationRef) ConversationKey() string {
+	return r.PageId + ":" + r.ThreadId
+}
+
+// ConversationRef returns the conversation the entry is part of, from the page, which is
+// the recipient of the entry, and the user, which is the sender. For message echoes the
+// page is the sender, so the two are swapped.
+func (e *MessagingEntry) ConversationRef() ConversationRef {
+	if e.Message != nil && e.Message.IsEcho {
+		return ConversationRef{ThreadId: e.Recipient.Id, PageId: e.Sender.Id}
+	}
+
+	return ConversationRef{ThreadId: e.Sender.Id, PageId: e.Recipient.Id}
+}
+
+// UserID returns the Id of the user who interacted with the page, which for all callbacks
+// other than message echoes is the sender of the entry.
+func UserID(entry *MessagingEntry) string {
+	return entry.SenderID()
+}
+
+// Principal holds the Id of a sender or recipient. On Instagram callbacks IgId may also be
+// set to the Instagram account id of the principal.
+type Principal struct {
+	Id   string `json:"id" binding:"required"`
+	IgId string `json:"ig_id,omitempty"`
+}
+
+// String returns the Id of the principal with a "psid:" prefix, such as
+// "psid:1254459154682919", so that it prints readably in logs. The prefix is added to page
+// ids too, as a principal does not know whether it is a page; see IsPage.
+func (p Principal) String() string {
+	return "psid:" + p.Id
+}
+
+// IsPage reports whether the Id of the principal is one of the given page ids. It is false
+// when no page ids are given.
+func (p Principal) IsPage(knownPageIds ...string) bool {
+	for _, pageId := range knownPageIds {
+		if p.Id == pageId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUser reports whether the principal has an Id that is not one of the given page ids.
+func (p Principal) IsUser(knownPageIds ...string) bool {
+	return p.Id != "" && !p.IsPage(knownPageIds...)
+}
+
+// Equals reports whether the principal has the same Id as other. IgId is not compared, as
+// it is only set on some Instagram callbacks.
+func (p Principal) Equals(other Principal) bool {
+	return p.Id == other.Id
+}
+
+/*
+CallbackMessage represents a message a user has sent to your page.
+Either the Text or Attachments field will be set, but not both.
+
+When echoes are enabled, messages sent by your page are also delivered as callbacks, with
+IsEcho set and the page as the sender. AppId, Metadata and PersonaId are only set on echoes.
+
+MessageId holds the "mid" field of the callback, which is the same as the MessageId of the
+SendResponse for a message sent by your page. Use ID to get it.
+
+See https://developers.facebook.com/docs/messenger-platform/webhook-reference/message-received
+and https://developers.facebook.com/docs/messenger-platform/webhook-reference/message-echo
+*/
+type CallbackMessage struct {
+	MessageId    string                `json:"mid" binding:"required"`
+	Sequence     int                   `json:"seq" binding:"required"`
+	Text         string                `json:"text"`
+	Attachments  []*CallbackAttachment `json:"attachments"`
+	QuickReply   *CallbackQuickReply   `json:"quick_reply"`
+	IsEcho       bool                  `json:"is_echo"`
+	AppId        string                `json:"app_id"`
+	Metadata     string                `json:"metadata"`
+	PersonaId    string                `json:"persona_id"`
+	StoryMention *StoryMention         `json:"story_mention,omitempty"`
+	StickerId    int64                 `json:"sticker_id,omitempty"`
+	ReplyTo      *ReplyTo              `json:"reply_to,omitempty"`
+}
+
+// ReplyTo identifies the message a user replied to. On Instagram, Story is set instead
+// when the user replied to a story.
+type ReplyTo struct {
+	MessageId string      `json:"mid,omitempty"`
+	Story     *StoryReply `json:"story,omitempty"`
+}
+
+// StoryReply identifies the Instagram story a user replied to.
+type StoryReply struct {
+	URL string `json:"url"`
+	Id  string `json:"id"`
+}
+
+// IsReply reports whether the message is a reply to another message or a story.
+func (m *CallbackMessage) IsReply() bool {
+	return m.ReplyTo != nil
+}
+
+// IsStoryReply reports whether the message is a reply to a story.
+func (m *CallbackMessage) IsStoryReply() bool {
+	return m.ReplyTo != nil && m.ReplyTo.Story != nil
+}
+
+// RepliedStoryURL returns the URL of the story the message is a reply to. The boolean is
+// false when the message is not a reply to a story.
+func (m *CallbackMessage) RepliedStoryURL() (string, bool) {
+	if !m.IsStoryReply() {
+		return "", false
+	}
+
+	return m.ReplyTo.Story.URL, true
+}
+
+// LikeStickerId is the id of the thumbs up "like" sticker sent with the like button. The
+// button sends a larger version of the sticker the longer it is held, using the ids in
+// likeStickerIds.
+const LikeStickerId int64 = 369239263222822
+
+var likeStickerIds = []int64{LikeStickerId, 369239343222814, 369239383222810}
+
+// IsSticker reports whether the message is a sticker.
+func (m *CallbackMessage) IsSticker() bool {
+	return m.StickerId != 0
+}
+
+// IsLike reports whether the message is the thumbs up "like" sticker, in any of its sizes.
+func (m *CallbackMessage) IsLike() bool {
+	for _, id := range likeStickerIds {
+		if m.StickerId == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StoryMention is set on Instagram messages sent when a user mentions your account in
+// their story. Link is the URL of the story's media.
+type StoryMention struct {
+	Link string `json:"link"`
+	Id   string `json:"id"`
+}
+
+// IsStoryMention reports whether the message is an Instagram story mention.
+func (m *CallbackMessage) IsStoryMention() bool {
+	return m.StoryMention != nil
+}
+
+// SentByPersona reports whether the message is an echo of a message the page sent
+// using a persona.
+func (m *CallbackMessage) SentByPersona() bool {
+	return m.PersonaId != ""
+}
+
+// ID returns the Id of the message, which is the "mid" field of the callback.
+func (m *CallbackMessage) ID() string {
+	return m.MessageId
+}
+
+// IsEchoOf reports whether the message is the echo of the message with the given Id, such
+// as the MessageId of a SendResponse.
+func (m *CallbackMessage) IsEchoOf(messageId string) bool {
+	return m.IsEcho && m.MessageId == messageId
+}
+
+// AttachmentCount returns the number of attachments in the message.
+func (m *CallbackMessage) AttachmentCount() int {
+	return len(m.Attachments)
+}
+
+// HasText reports whether the message contains text.
+func (m *CallbackMessage) HasText() bool {
+	return m.Text != ""
+}
+
+// HasAttachments reports whether the message contains one or more attachments.
+func (m *CallbackMessage) HasAttachments() bool {
+	return len(m.Attachments) > 0
+}
+
+// IsTextOnly reports whether the message contains text and no attachments.
+func (m *CallbackMessage) IsTextOnly() bool {
+	return m.HasText() && !m.HasAttachments()
+}
+
+// IsAttachmentOnly reports whether the message contains attachments and no text.
+func (m *CallbackMessage) IsAttachmentOnly() bool {
+	return m.HasAttachments() && !m.HasText()
+}
+
+// TextOrEmpty returns the text of the message, or an empty string if the message
+// is nil or has no text.
+func (m *CallbackMessage) TextOrEmpty() string {
+	if m == nil {
+		return ""
+	}
+
+	return m.Text
+}
+
+// CallbackAttachment holds the type and payload of an attachment sent by a user.
+type CallbackAttachment struct {
+	Title   string                    `json:"title,omitempty"`
+	URL     string                    `json:"url"`
+	Type    string                    `json:"type" binding:"required"`
+	Payload CallbackAttachmentPayload `json:"payload" binding:"required"`
+}
+
+// CallbackAttachmentType returns the type of the attachment as a CallbackAttachmentType. The
+// type is returned as it is even when it is not one of the constants.
+func (a *CallbackAttachment) CallbackAttachmentType() CallbackAttachmentType {
+	return CallbackAttachmentType(a.Type)
+}
+
+// IsGIF reports whether the attachment is an animated GIF. Facebook sends GIFs as image
+// attachments, so they are told apart by the extension of the URL.
+func (a *CallbackAttachment) IsGIF() bool {
+	if a.Type != string(CallbackAttachmentTypeImage) {
+		return false
+	}
+
+	u, err := url.Parse(a.Payload.URL)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasSuffix(strings.ToLower(u.Path), ".gif")
+}
+
+// LocationTitle returns the title of a shared location, such as "Person's Location",
+// using the title of the payload when the attachment itself has none.
+func (a *CallbackAttachment) LocationTitle() string {
+	if a.Title != "" {
+		return a.Title
+	}
+
+	return a.Payload.Title
+}
+
+// CallbackAttachmentType identifies the kind of an attachment sent by a user.
+type CallbackAttachmentType string
+
+const (
+	CallbackAttachmentTypeAudio    CallbackAttachmentType = "audio"
+	CallbackAttachmentTypeVideo    CallbackAttachmentType = "video"
+	CallbackAttachmentTypeImage    CallbackAttachmentType = "image"
+	CallbackAttachmentTypeFile     CallbackAttachmentType = "file"
+	CallbackAttachmentTypeLocation CallbackAttachmentType = "location"
+	CallbackAttachmentTypeFallback CallbackAttachmentType = "fallback"
+)
+
+// ParseCallbackAttachmentType converts s to a CallbackAttachmentType, returning false when it
+// is not one of the constants, such as for an attachment type added by Facebook since.
+func ParseCallbackAttachmentType(s string) (CallbackAttachmentType, bool) {
+	switch t := CallbackAttachmentType(s); t {
+	case CallbackAttachmentTypeAudio, CallbackAttachmentTypeVideo, CallbackAttachmentTypeImage,
+		CallbackAttachmentTypeFile, CallbackAttachmentTypeLocation, CallbackAttachmentTypeFallback:
+		return t, true
+	default:
+		return t, false
+	}
+}
+
+// CallbackAttachmentPayload holds the URL of a multimedia attachment, or the coordinates
+// of a location attachment sent by the user. StickerId is set when the attachment is a sticker.
+type CallbackAttachmentPayload struct {
+	URL         string       `json:"url"`
+	Title       string       `json:"title,omitempty"`
+	Coordinates *Coordinates `json:"coordinates"`
+	StickerId   int64        `json:"sticker_id,omitempty"`
+}
+
+// Coordinates holds the latitude and longitude of a location.
+type Coordinates struct {
 	Lat  float64 `json:"lat" binding:"required"`
 	Long float64 `json:"long" binding:"required"`
 }
@@ -532,8 +2088,90 @@ See https://developers.facebook.com/docs/messenger-platform/webhook-reference/me
 */
 type Delivery struct {
 	MessageIds []string `json:"mids"`
-	Watermark  int      `json:"watermark" binding:"required"`
-	Sequence   int      `json:"seq" bindging:"required"`
+	Watermark  int64    `json:"watermark" binding:"required"`
+	Sequence   int      `json:"seq" binding:"required"`
+}
+
+// WatermarkTime returns the Watermark as a time.Time. All messages sent before this
+// time have been delivered.
+func (d *Delivery) WatermarkTime() time.Time {
+	return ParseTimestamp(d.Watermark)
+}
+
+// DeliveredMessages returns the Ids of the messages that were delivered. Facebook may
+// omit these, in which case use WatermarkTime instead.
+func (d *Delivery) DeliveredMessages() []string {
+	return d.MessageIds
+}
+
+/*
+Read indicates that the user has read the messages sent by the page.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/webhook-events/message-reads
+*/
+type Read struct {
+	Watermark int64 `json:"watermark" binding:"required"`
+	Sequence  int   `json:"seq"`
+}
+
+// WatermarkTime returns the Watermark as a time.Time. All messages sent before this
+// time have been read.
+func (r *Read) WatermarkTime() time.Time {
+	return ParseTimestamp(r.Watermark)
+}
+
+// IsAfter reports whether the read has a later watermark than the other read, for ordering
+// reads that arrive out of order.
+func (r *Read) IsAfter(other Read) bool {
+	return r.Watermark > other.Watermark
+}
+
+// IndicatesAllRead reports whether the read has a watermark, meaning every message sent
+// before WatermarkTime has been read.
+func (r *Read) IndicatesAllRead() bool {
+	return r.Watermark > 0
+}
+
+/*
+FeedbackResponse holds the answers of a user to a customer feedback template message, with
+one FeedbackScreenResponse for each screen of questions.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/webhook-events/messaging_feedback
+*/
+type FeedbackResponse struct {
+	FeedbackScreens []*FeedbackScreenResponse `json:"feedback_screens" binding:"required"`
+}
+
+// FeedbackScreenResponse holds the answers to the questions of one screen, by question Id.
+type FeedbackScreenResponse struct {
+	ScreenId  int                        `json:"screen_id"`
+	Questions map[string]*FeedbackAnswer `json:"questions" binding:"required"`
+}
+
+// FeedbackAnswer is the answer to a question, with the score as its Payload, and the answer
+// to its follow up, if any.
+type FeedbackAnswer struct {
+	Type     string                  `json:"type" binding:"required"`
+	Payload  string                  `json:"payload" binding:"required"`
+	FollowUp *FeedbackFollowUpAnswer `json:"follow_up,omitempty"`
+}
+
+// FeedbackFollowUpAnswer is the free form answer to the follow up of a question.
+type FeedbackFollowUpAnswer struct {
+	Type    string `json:"type" binding:"required"`
+	Payload string `json:"payload"`
+}
+
+// ParseFeedbackCallback returns the first FeedbackResponse in the callback. An error is
+// returned when the callback holds no feedback.
+func ParseFeedbackCallback(cb *Callback) (*FeedbackResponse, error) {
+	for _, messagingEntry := range cb.FlattenMessaging() {
+		if messagingEntry.Feedback != nil {
+			return messagingEntry.Feedback, nil
+		}
+	}
+
+	return nil, fmt.Errorf("callback has no messaging feedback")
 }
 
 /*
@@ -542,16 +2180,524 @@ Postback holds the data defined for buttons the user taps.
 See https://developers.facebook.com/docs/messenger-platform/webhook-reference/postback-received
 */
 type Postback struct {
-	Payload string `json:"payload" binding:"required"`
+	Payload  string    `json:"payload" binding:"required"`
+	Referral *Referral `json:"referral,omitempty"`
+}
+
+// PayloadString returns the Payload of the postback as is.
+func (p *Postback) PayloadString() string {
+	return p.Payload
+}
+
+// DecodePayload unmarshals a Payload holding JSON, such as one created with
+// NewPostbackPayload, into v.
+func (p *Postback) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(p.Payload), v)
+}
+
+/*
+Referral describes how a user entered a conversation: through an m.me link with a ref
+parameter, an ad, or a parametric Messenger code. Ref is the ref parameter, and AdId and
+AdsContextData are set for referrals from ads.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/webhook-events/messaging_referrals
+*/
+type Referral struct {
+	Ref            string            `json:"ref,omitempty"`
+	Source         string            `json:"source" binding:"required"`
+	Type           string            `json:"type" binding:"required"`
+	AdId           string            `json:"ad_id,omitempty"`
+	AdsContextData *AdContextualData `json:"ads_context_data,omitempty"`
+	RefererURI     string            `json:"referer_uri,omitempty"`
+}
+
+// AdContextualData holds the details of the ad a user clicked to enter a conversation.
+type AdContextualData struct {
+	AdTitle  string `json:"ad_title"`
+	PhotoURL string `json:"photo_url,omitempty"`
+	VideoURL string `json:"video_url,omitempty"`
+	PostId   string `json:"post_id"`
 }
 
 /*
-OptIn holds the data defined for the Send-to-Messenger plugin.
+OptIn holds the data defined for the Send-to-Messenger plugin. When a user agrees to a
+one-time notification request, Type is "one_time_notif_req" and Ref is not set. Instead
+Payload holds the payload of the request and OneTimeNotifToken the token to send with.
+When a user ticks the Checkbox plugin, UserRef is set and the sender of the callback is
+not, so reply to the user with a Recipient with the UserRef set.
 
 See https://developers.facebook.com/docs/messenger-platform/webhook-reference/authentication
 */
 type OptIn struct {
-	Ref string `json:"ref" binding:"required"`
+	Ref               string `json:"ref"`
+	Type              string `json:"type,omitempty"`
+	Payload           string `json:"payload,omitempty"`
+	OneTimeNotifToken string `json:"one_time_notif_token,omitempty"`
+	UserRef           string `json:"user_ref,omitempty"`
+}
+
+// DecodeRef unmarshals a Ref holding JSON, such as one created with EncodeOptInRef, into v.
+func (o *OptIn) DecodeRef(v interface{}) error {
+	return DecodeOptInRef(o.Ref, v)
+}
+
+// RefBase64 returns the bytes of a Ref holding standard base64.
+func (o *OptIn) RefBase64() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(o.Ref)
+}
+
+// IsOneTimeNotification reports whether the user agreed to a one-time notification request.
+func (o *OptIn) IsOneTimeNotification() bool {
+	return o.OneTimeNotifToken != ""
+}
+
+// IsCheckboxPlugin reports whether the user opted in with the Checkbox plugin.
+func (o *OptIn) IsCheckboxPlugin() bool {
+	return o.UserRef != ""
+}
+
+/*
+EncodeOptInRef encodes a value as a JSON string for use as the data-ref of the
+Send-to-Messenger plugin or the ref of an m.me link. Decode it from the OptIn callback
+with DecodeOptInRef. An error is returned when the JSON is longer than MaxOptInRefSize.
+*/
+func EncodeOptInRef(v interface{}) (string, error) {
+	refBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateLength("OptIn.Ref", string(refBytes), MaxOptInRefSize); err != nil {
+		return "", err
+	}
+
+	return string(refBytes), nil
+}
+
+// DecodeOptInRef unmarshals a ref holding JSON, such as one created with EncodeOptInRef,
+// into v.
+func DecodeOptInRef(ref string, v interface{}) error {
+	return json.Unmarshal([]byte(ref), v)
+}
+
+/*------------------------------------------------------
+Messenger Profile
+------------------------------------------------------*/
+
+/*
+MessengerProfileResponse holds the messenger profile properties of a page returned by
+GetMessengerProfile. Properties that are not set, or were not requested, are left empty.
+
+See https://developers.facebook.com/docs/messenger-platform/messenger-profile
+*/
+type MessengerProfileResponse struct {
+	GetStartedPayload  string
+	GreetingTexts      []*Greeting
+	PersistentMenus    []*PersistentMenu
+	WhitelistedDomains []string
+	AccountLinkingURL  string
+	IceBreakers        []*IceBreaker
+	HomeURL            *HomeURL
+}
+
+// Locale identifies the locale of the users a messenger profile property is shown to.
+type Locale string
+
+const (
+	LocaleDefault Locale = "default"
+	LocaleArAR    Locale = "ar_AR"
+	LocaleDeDE    Locale = "de_DE"
+	LocaleEnGB    Locale = "en_GB"
+	LocaleEnUS    Locale = "en_US"
+	LocaleEsES    Locale = "es_ES"
+	LocaleEsLA    Locale = "es_LA"
+	LocaleFrCA    Locale = "fr_CA"
+	LocaleFrFR    Locale = "fr_FR"
+	LocaleHiIN    Locale = "hi_IN"
+	LocaleIdID    Locale = "id_ID"
+	LocaleItIT    Locale = "it_IT"
+	LocaleJaJP    Locale = "ja_JP"
+	LocaleKoKR    Locale = "ko_KR"
+	LocaleNlNL    Locale = "nl_NL"
+	LocalePlPL    Locale = "pl_PL"
+	LocalePtBR    Locale = "pt_BR"
+	LocalePtPT    Locale = "pt_PT"
+	LocaleRuRU    Locale = "ru_RU"
+	LocaleThTH    Locale = "th_TH"
+	LocaleTrTR    Locale = "tr_TR"
+	LocaleViVN    Locale = "vi_VN"
+	LocaleZhCN    Locale = "zh_CN"
+	LocaleZhTW    Locale = "zh_TW"
+)
+
+var supportedLocales = []Locale{
+	LocaleDefault, LocaleArAR, LocaleDeDE, LocaleEnGB, LocaleEnUS, LocaleEsES, LocaleEsLA, LocaleFrCA,
+	LocaleFrFR, LocaleHiIN, LocaleIdID, LocaleItIT, LocaleJaJP, LocaleKoKR, LocaleNlNL, LocalePlPL,
+	LocalePtBR, LocalePtPT, LocaleRuRU, LocaleThTH, LocaleTrTR, LocaleViVN, LocaleZhCN, LocaleZhTW,
+}
+
+// SupportedLocales returns the locales that have constants, starting with LocaleDefault.
+func SupportedLocales() []Locale {
+	locales := make([]Locale, len(supportedLocales))
+	copy(locales, supportedLocales)
+
+	return locales
+}
+
+/*
+Greeting is the text shown on the welcome screen of a conversation for users of one locale.
+Use LocaleDefault for users of all other locales.
+
+See https://developers.facebook.com/docs/messenger-platform/messenger-profile/greeting-text
+*/
+type Greeting struct {
+	Locale Locale `json:"locale" binding:"required"`
+	Text   string `json:"text" binding:"required"`
+}
+
+// SetLocale sets the locale of the users the greeting is shown to.
+func (g *Greeting) SetLocale(locale Locale) *Greeting {
+	g.Locale = locale
+
+	return g
+}
+
+/*
+PersistentMenu is the menu that is always available in a conversation for users of one
+locale. Use the locale "default" for users of all other locales.
+
+See https://developers.facebook.com/docs/messenger-platform/messenger-profile/persistent-menu
+*/
+type PersistentMenu struct {
+	Locale                string    `json:"locale" binding:"required"`
+	ComposerInputDisabled bool      `json:"composer_input_disabled,omitempty"`
+	CallToActions         []*Button `json:"call_to_actions,omitempty"`
+}
+
+/*
+IceBreaker is a question shown to a user when they first open a conversation with the page.
+When the user taps the question, the page receives a postback with the Payload.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messenger-profile-api/ice-breakers
+*/
+type IceBreaker struct {
+	Question string `json:"question" binding:"required"`
+	Payload  string `json:"payload" binding:"required"`
+}
+
+/*
+HomeURL is the page opened in the Messenger webview for the chat extension of a page. The
+URL must use HTTPS and its domain must be whitelisted for the page. Set InTest to only show
+the chat extension to admins and testers of the page.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messenger-profile-api/home-url
+*/
+type HomeURL struct {
+	URL                string `json:"url" binding:"required"`
+	WebviewHeightRatio string `json:"webview_height_ratio" binding:"required"`
+	WebviewShareButton string `json:"webview_share_button,omitempty"`
+	InTest             bool   `json:"in_test"`
+}
+
+/*
+MessengerFeature is the review status of a feature that a page must be approved for before
+using it, such as FeaturePayments. Status is one of the FeatureStatus constants.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messaging-feature-review-api
+*/
+type MessengerFeature struct {
+	Feature string `json:"feature" binding:"required"`
+	Status  string `json:"status" binding:"required"`
+}
+
+// Names of features that require review.
+const (
+	FeaturePayments              = "payments"
+	FeatureNLP                   = "nlp"
+	FeatureSubscriptionMessaging = "subscription_messaging"
+	FeatureOneTimeNotification   = "one_time_notification"
+)
+
+// Values for the Status of a MessengerFeature.
+const (
+	FeatureStatusApproved = "APPROVED"
+	FeatureStatusPending  = "PENDING"
+	FeatureStatusRejected = "REJECTED"
+)
+
+// MessengerFeatures holds the review status of each feature the page has submitted for review.
+type MessengerFeatures []*MessengerFeature
+
+// FeaturesMap returns the status of each feature keyed by the name of the feature.
+func (features MessengerFeatures) FeaturesMap() FeatureStatusMap {
+	statuses := FeatureStatusMap{}
+	for _, feature := range features {
+		statuses[feature.Feature] = feature.Status
+	}
+
+	return statuses
+}
+
+// FeatureStatusMap holds the review status of features keyed by the name of the feature.
+type FeatureStatusMap map[string]string
+
+// IsApproved reports whether the feature has been approved for the page. Facebook has sent
+// statuses in both upper and lower case, so the comparison ignores case.
+func (statuses FeatureStatusMap) IsApproved(feature string) bool {
+	return strings.EqualFold(statuses[feature], FeatureStatusApproved)
+}
+
+/*------------------------------------------------------
+Handover Protocol
+------------------------------------------------------*/
+
+/*
+HandoverMetadata is structured metadata sent along with a change in thread control, to
+tell the receiving app why the conversation was handed over. It is sent to Facebook as a
+JSON encoded string.
+*/
+type HandoverMetadata struct {
+	Reason     string                 `json:"reason,omitempty"`
+	RequestId  string                 `json:"request_id,omitempty"`
+	CustomData map[string]interface{} `json:"custom_data,omitempty"`
+}
+
+// Marshal returns the metadata JSON encoded as a string, as it is sent to Facebook. An empty
+// string is returned if CustomData holds a value that cannot be encoded.
+func (m *HandoverMetadata) Marshal() string {
+	metadataBytes, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+
+	return string(metadataBytes)
+}
+
+// AppRole is the role of an app in the handover protocol.
+type AppRole string
+
+const (
+	AppRolePrimaryReceiver   AppRole = "primary_receiver"
+	AppRoleSecondaryReceiver AppRole = "secondary_receiver"
+)
+
+// ThreadOwnerResponse identifies the app that currently controls the conversation with a user.
+type ThreadOwnerResponse struct {
+	AppId string  `json:"app_id" binding:"required"`
+	Role  AppRole `json:"role,omitempty"`
+}
+
+// IsPrimaryReceiver reports whether the thread owner is the primary receiver app.
+func (r *ThreadOwnerResponse) IsPrimaryReceiver() bool {
+	return r.Role == AppRolePrimaryReceiver
+}
+
+// IsSecondaryReceiver reports whether the thread owner is a secondary receiver app.
+func (r *ThreadOwnerResponse) IsSecondaryReceiver() bool {
+	return r.Role == AppRoleSecondaryReceiver
+}
+
+/*
+HandoverEvent is a messaging entry received on the standby channel, which delivers the
+conversations an app does not control, along with the roles of the apps of the page, by app
+Id. Use the roles to decide whether to process or ignore the entry.
+*/
+type HandoverEvent struct {
+	Entry    *MessagingEntry
+	AppRoles map[string][]string
+}
+
+// HasRole reports whether the app with the given Id has the role.
+func (e *HandoverEvent) HasRole(appId string, role AppRole) bool {
+	for _, r := range e.AppRoles[appId] {
+		if AppRole(r) == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Reaction holds the details of a user reacting to a message, or removing their reaction.
+ReactionType is one of the Reaction* constants and Action is ReactionActionReact or
+ReactionActionUnreact.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/webhook-events/message-reactions
+*/
+type Reaction struct {
+	Action       string `json:"action" binding:"required"`
+	Emoji        string `json:"emoji"`
+	EmojiStr     string `json:"emoji_str"`
+	ReactionType string `json:"reaction"`
+	MessageId    string `json:"mid" binding:"required"`
+}
+
+// Values for the Action of a Reaction.
+const (
+	ReactionActionReact   = "react"
+	ReactionActionUnreact = "unreact"
+)
+
+// Values for the ReactionType of a Reaction.
+const (
+	ReactionSmile   = "smile"
+	ReactionAngry   = "angry"
+	ReactionSad     = "sad"
+	ReactionWow     = "wow"
+	ReactionLove    = "love"
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+	ReactionHaha    = "haha"
+	ReactionOther   = "other"
+)
+
+// MessageDelete holds the Id of a message the user has unsent.
+type MessageDelete struct {
+	MessageId string `json:"mid" binding:"required"`
+}
+
+/*------------------------------------------------------
+Broadcasts
+------------------------------------------------------*/
+
+/*
+MessageCreativeRequest creates a message creative, a message stored by Facebook for sending
+as a broadcast.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/broadcast-messages
+*/
+type MessageCreativeRequest struct {
+	Messages []*Message `json:"messages" binding:"required"`
+}
+
+// MessageCreative is a message creative created with Client.CreateMessageCreative. Pass its
+// Id to Client.Broadcast, or call its Broadcast method.
+type MessageCreative struct {
+	Id int64 `json:"message_creative_id" binding:"required"`
+}
+
+// Broadcast is a fluent helper method for broadcasting the message creative straight away
+// with client, returning the id of the broadcast.
+func (mc *MessageCreative) Broadcast(client *Client, pageAccessToken string) (int64, error) {
+	return mc.BroadcastWithContext(context.Background(), client, pageAccessToken)
+}
+
+// BroadcastWithContext is like Broadcast but allows you to timeout or cancel the request using context.Context.
+func (mc *MessageCreative) BroadcastWithContext(ctx context.Context, client *Client, pageAccessToken string) (int64, error) {
+	return client.BroadcastWithContext(ctx, &BroadcastRequest{MessageCreativeId: mc.Id}, pageAccessToken)
+}
+
+/*
+BroadcastRequest sends a message creative to the users subscribed to the page, either
+straight away or, when ScheduledTime is set, at that time, which must be at least
+MinBroadcastScheduleDelay in the future.
+
+See https://developers.facebook.com/docs/messenger-platform/send-messages/broadcast-messages
+*/
+type BroadcastRequest struct {
+	MessageCreativeId int64      `json:"message_creative_id" binding:"required"`
+	NotificationType  string     `json:"notification_type,omitempty"`
+	CustomLabelId     int64      `json:"custom_label_id,omitempty"`
+	ScheduledTime     *time.Time `json:"-"`
+}
+
+// MarshalJSON encodes the request, with ScheduledTime as the schedule_time Facebook expects,
+// in seconds since the epoch.
+func (r *BroadcastRequest) MarshalJSON() ([]byte, error) {
+	type broadcastRequest BroadcastRequest
+
+	request := &struct {
+		*broadcastRequest
+		ScheduleTime int64 `json:"schedule_time,omitempty"`
+	}{broadcastRequest: (*broadcastRequest)(r)}
+
+	if r.ScheduledTime != nil {
+		request.ScheduleTime = r.ScheduledTime.Unix()
+	}
+
+	return json.Marshal(request)
+}
+
+/*
+ABTestBroadcast is one variant of an A/B test sent with Client.ABTestBroadcast. Each variant
+is created as a message creative of its own and broadcast separately. Facebook has no split
+rate for broadcasts, so set CustomLabelId to send the variant to the users with a custom
+label, such as one applied to a share of the subscribers. Without it the variant is sent to
+all subscribers.
+*/
+type ABTestBroadcast struct {
+	Variant          string
+	Messages         []*Message
+	CustomLabelId    int64
+	NotificationType string
+}
+
+// BroadcastStatus is the state of a broadcast, returned by GetBroadcastStatus.
+type BroadcastStatus string
+
+const (
+	BroadcastStatusScheduled BroadcastStatus = "SCHEDULED"
+	BroadcastStatusRunning   BroadcastStatus = "RUNNING"
+	BroadcastStatusCompleted BroadcastStatus = "COMPLETED"
+	BroadcastStatusCancelled BroadcastStatus = "CANCELLED"
+)
+
+// The names of the broadcast metrics requested by GetBroadcastInsights.
+const (
+	BroadcastMetricMessagesSent      = "messages_sent"
+	BroadcastMetricMessagesDelivered = "messages_delivered"
+	BroadcastMetricMessagesRead      = "messages_read"
+	BroadcastMetricClicks            = "clicks"
+	BroadcastMetricEngagements       = "engagements"
+)
+
+/*
+BroadcastInsights holds the metrics of a broadcast, returned by GetBroadcastInsights. The
+value of each metric is the latest one reported.
+
+See https://developers.facebook.com/docs/messenger-platform/reference/messaging-insights-api
+*/
+type BroadcastInsights struct {
+	// ReachEstimate holds the messages_sent metric, the number of messages sent.
+	ReachEstimate int64
+
+	// TotalImpressions holds the messages_delivered metric, the number of messages delivered.
+	TotalImpressions int64
+
+	// UniqueImpressions holds the messages_read metric, the number of messages read.
+	UniqueImpressions int64
+
+	// Clicks holds the clicks metric, the number of clicks on the buttons of the messages.
+	Clicks int64
+
+	// Engagements holds the engagements metric, the number of replies and clicks.
+	Engagements int64
+}
+
+// EngagementRate is the number of engagements per impression, or 0 when there are no impressions.
+func (bi *BroadcastInsights) EngagementRate() float64 {
+	if bi.TotalImpressions == 0 {
+		return 0
+	}
+
+	return float64(bi.Engagements) / float64(bi.TotalImpressions)
+}
+
+// setMetric sets the field for the metric with the given name. Other metrics are ignored.
+func (bi *BroadcastInsights) setMetric(name string, value int64) {
+	switch name {
+	case BroadcastMetricMessagesSent:
+		bi.ReachEstimate = value
+	case BroadcastMetricMessagesDelivered:
+		bi.TotalImpressions = value
+	case BroadcastMetricMessagesRead:
+		bi.UniqueImpressions = value
+	case BroadcastMetricClicks:
+		bi.Clicks = value
+	case BroadcastMetricEngagements:
+		bi.Engagements = value
+	}
 }
 
 /*------------------------------------------------------
@@ -570,4 +2716,206 @@ type UserProfile struct {
 	Locale          string `json:"locale"`
 	Timezone        int    `json:"timezone"`
 	Gender          string `json:"gender"`
+
+	PaymentPricePoints *PaymentPricePoints `json:"payment_price_points,omitempty"`
+	RequestedUserInfo  *RequestedUserInfo  `json:"requested_user_info,omitempty"`
+}
+
+// Sizes, in pixels, for UserProfile.AvatarURL.
+const (
+	AvatarSizeSmall  = 50
+	AvatarSizeMedium = 100
+	AvatarSizeLarge  = 200
+)
+
+// HasAvatar reports whether the profile has a profile photo URL.
+func (up *UserProfile) HasAvatar() bool {
+	return up.ProfilePhotoURL != ""
+}
+
+// AvatarURL returns the profile photo URL with the width and height set to size pixels,
+// or an empty string when the profile has no profile photo URL.
+func (up *UserProfile) AvatarURL(size int) string {
+	if !up.HasAvatar() {
+		return ""
+	}
+
+	avatarURL, err := url.Parse(up.ProfilePhotoURL)
+	if err != nil {
+		return up.ProfilePhotoURL
+	}
+
+	query := avatarURL.Query()
+	query.Set("width", strconv.Itoa(size))
+	query.Set("height", strconv.Itoa(size))
+	avatarURL.RawQuery = query.Encode()
+
+	return avatarURL.String()
+}
+
+// PaymentPricePoints holds the Facebook credits price points available to the user. It is
+// only returned by GetUserProfileFields when requested and the page has payments permissions.
+type PaymentPricePoints struct {
+	Credits []*PricePoint `json:"credits"`
+}
+
+// PricePoint is a number of Facebook credits and its price in the user's local currency.
+type PricePoint struct {
+	Credits       int64   `json:"credits"`
+	LocalCurrency float64 `json:"local_currency"`
+}
+
+// RequestedUserInfo holds the contact and shipping details a user has shared through
+// Messenger payments. It is only returned when requested and the page has payments permissions.
+type RequestedUserInfo struct {
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	ContactName     string   `json:"contact_name,omitempty"`
+	ContactEmail    string   `json:"contact_email,omitempty"`
+	ContactPhone    string   `json:"contact_phone,omitempty"`
+}
+
+/*------------------------------------------------------
+Page Info
+------------------------------------------------------*/
+
+/*
+PageInfo holds details of the page, as returned by Client.GetPageInfo. Only the fields that
+were requested are set.
+
+See https://developers.facebook.com/docs/graph-api/reference/page
+*/
+type PageInfo struct {
+	Id       string       `json:"id"`
+	Name     string       `json:"name,omitempty"`
+	Category string       `json:"category,omitempty"`
+	Picture  *PagePicture `json:"picture,omitempty"`
+	FanCount int64        `json:"fan_count,omitempty"`
+}
+
+// PagePicture is the profile picture of a page. IsSilhouette is true when the page has not
+// set a picture and the default silhouette is shown.
+type PagePicture struct {
+	URL          string `json:"url"`
+	IsSilhouette bool   `json:"is_silhouette"`
+}
+
+// UnmarshalJSON decodes a picture, which Facebook wraps in a data object.
+func (p *PagePicture) UnmarshalJSON(data []byte) error {
+	type pagePicture PagePicture
+
+	picture := &struct {
+		Data *pagePicture `json:"data"`
+	}{Data: (*pagePicture)(p)}
+
+	return json.Unmarshal(data, picture)
+}
+
+/*------------------------------------------------------
+Batch Requests
+------------------------------------------------------*/
+
+/*
+BatchGetRequest is one of the GET requests made together by Client.BatchGet. RelativeURL is
+the path and query of the request relative to the Graph API version, such as
+"USER_ID?fields=first_name", without an access token.
+
+See https://developers.facebook.com/docs/graph-api/batch-requests
+*/
+type BatchGetRequest struct {
+	RelativeURL string
+}
+
+// UserProfileBatchRequest creates a BatchGetRequest for the profile of a user, with the
+// given fields or, when there are none, the fields requested by GetUserProfile.
+func UserProfileBatchRequest(psid string, fields ...string) *BatchGetRequest {
+	if len(fields) == 0 {
+		fields = userProfileFields
+	}
+
+	return &BatchGetRequest{
+		RelativeURL: url.PathEscape(psid) + "?" + url.Values{"fields": {strings.Join(fields, ",")}}.Encode(),
+	}
+}
+
+// BatchGetResponse is the response to one of the requests made by Client.BatchGet, with the
+// HTTP status code and JSON body of the response.
+type BatchGetResponse struct {
+	Code int
+	Body json.RawMessage
+}
+
+// UnmarshalJSON decodes a response, in which Facebook encodes the body as a string.
+func (r *BatchGetResponse) UnmarshalJSON(data []byte) error {
+	response := &struct {
+		Code int    `json:"code"`
+		Body string `json:"body"`
+	}{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return err
+	}
+
+	r.Code = response.Code
+	r.Body = json.RawMessage(response.Body)
+
+	return nil
+}
+
+// Unmarshal decodes the body of the response into v. An error in the body is returned as a
+// *SendError.
+func (r *BatchGetResponse) Unmarshal(v interface{}) error {
+	if sendError, _ := ParseSendError(r.Body); sendError != nil {
+		return sendError
+	}
+
+	return json.Unmarshal(r.Body, v)
+}
+
+/*------------------------------------------------------
+Access Tokens
+------------------------------------------------------*/
+
+/*
+TokenInfo describes an access token, as returned by Client.InspectToken. ExpiresAt is the
+zero time for tokens that do not expire, such as page access tokens obtained from long-lived
+user tokens.
+
+See https://developers.facebook.com/docs/graph-api/reference/debug_token
+*/
+type TokenInfo struct {
+	AppId     string
+	Type      string
+	ExpiresAt time.Time
+	IsValid   bool
+	Scopes    []string
+	UserId    string
+}
+
+// UnmarshalJSON decodes token info, in which Facebook gives the expiry in seconds since the
+// epoch, or 0 for tokens that do not expire.
+func (t *TokenInfo) UnmarshalJSON(data []byte) error {
+	info := &struct {
+		AppId     string   `json:"app_id"`
+		Type      string   `json:"type"`
+		ExpiresAt int64    `json:"expires_at"`
+		IsValid   bool     `json:"is_valid"`
+		Scopes    []string `json:"scopes"`
+		UserId    string   `json:"user_id"`
+	}{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return err
+	}
+
+	*t = TokenInfo{
+		AppId:   info.AppId,
+		Type:    info.Type,
+		IsValid: info.IsValid,
+		Scopes:  info.Scopes,
+		UserId:  info.UserId,
+	}
+
+	if info.ExpiresAt != 0 {
+		t.ExpiresAt = time.Unix(info.ExpiresAt, 0).UTC()
+	}
+
+	return nil
 }
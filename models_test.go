@@ -7,9 +7,12 @@ import (
 	. "github.com/onsi/gomega"
 
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
+	"time"
 )
 
 var _ = Describe("Callback Models", func() {
@@ -30,6 +33,24 @@ var _ = Describe("Callback Models", func() {
 			Expect(message.QuickReply.Payload).To(Equal("DEVELOPER_DEFINED_PAYLOAD"))
 		})
 
+		It("should unmarshal an echo of a message sent using a persona", func() {
+			var cb Callback
+			loadCallback("message-echo-with-persona.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.IsEcho).To(BeTrue())
+			Expect(message.AppId).To(Equal("1517776481860111"))
+			Expect(message.PersonaId).To(Equal("1900267503460484"))
+			Expect(message.SentByPersona()).To(BeTrue())
+		})
+
+		It("should not report a message from a user as sent by a persona", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			Expect(cb.Entries[0].Messaging[0].Message.SentByPersona()).To(BeFalse())
+		})
+
 		It("should unmarshal a callback with a message with an image attachment", func() {
 			var cb Callback
 			loadCallback("message-with-image-attachment.json", &cb)
@@ -41,6 +62,22 @@ var _ = Describe("Callback Models", func() {
 			Expect(attachment.Payload.URL).To(Equal("IMAGE_URL"))
 		})
 
+		It("should unmarshal a callback with a message with gif, audio and unknown attachments", func() {
+			var cb Callback
+			loadCallback("message-with-gif-and-audio-attachments.json", &cb)
+
+			attachments := cb.Entries[0].Messaging[0].Message.Attachments
+			Expect(attachments).To(HaveLen(3))
+			Expect(attachments[0].CallbackAttachmentType()).To(Equal(CallbackAttachmentTypeImage))
+			Expect(attachments[0].IsGIF()).To(BeTrue())
+			Expect(attachments[1].CallbackAttachmentType()).To(Equal(CallbackAttachmentTypeAudio))
+			Expect(attachments[1].IsGIF()).To(BeFalse())
+
+			attachmentType, ok := ParseCallbackAttachmentType(attachments[2].Type)
+			Expect(ok).To(BeFalse())
+			Expect(attachmentType).To(Equal(CallbackAttachmentType("story_mention")))
+		})
+
 		It("should unmarshal a callback with a message with a location attachment", func() {
 			var cb Callback
 			loadCallback("message-with-location-attachment.json", &cb)
@@ -51,6 +88,374 @@ var _ = Describe("Callback Models", func() {
 			Expect(attachment.Type).To(Equal("location"))
 			Expect(attachment.Payload.Coordinates.Lat).To(Equal(37.483872693672))
 			Expect(attachment.Payload.Coordinates.Long).To(Equal(-122.14900441942))
+			Expect(attachment.Title).To(Equal("Facebook HQ"))
+			Expect(attachment.LocationTitle()).To(Equal("Facebook HQ"))
+		})
+
+		It("should use the payload title of a location attachment without a title", func() {
+			var cb Callback
+			loadCallback("message-with-location-attachment-payload-title.json", &cb)
+
+			attachment := cb.Entries[0].Messaging[0].Message.Attachments[0]
+			Expect(attachment.Title).To(Equal(""))
+			Expect(attachment.Payload.Title).To(Equal("Person's Location"))
+			Expect(attachment.LocationTitle()).To(Equal("Person's Location"))
+		})
+
+		It("should unmarshal a callback with a sticker", func() {
+			var cb Callback
+			loadCallback("message-with-sticker.json", &cb)
+
+			attachment := cb.Entries[0].Messaging[0].Message.Attachments[0]
+			Expect(attachment.Type).To(Equal("image"))
+			Expect(attachment.Title).To(Equal("Thumbs Up"))
+			Expect(attachment.Payload.StickerId).To(Equal(int64(369239263222822)))
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.StickerId).To(Equal(LikeStickerId))
+			Expect(message.IsSticker()).To(BeTrue())
+			Expect(message.IsLike()).To(BeTrue())
+		})
+
+		It("should unmarshal a reply to a message", func() {
+			var cb Callback
+			loadCallback("message-reply.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.IsReply()).To(BeTrue())
+			Expect(message.ReplyTo.MessageId).To(Equal("m_1Ku2hQ5zPXzcVX-MAr9v-ZubfPRhIx9nnuDFyMe3yGGgt-k-W-6UfNLI9Vlb0T1DjQyz3TYYZxKv0U7jIEQjiQ"))
+			Expect(message.ReplyTo.Story).To(BeNil())
+			Expect(message.IsStoryReply()).To(BeFalse())
+
+			_, ok := message.RepliedStoryURL()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should unmarshal a reply to a story", func() {
+			var cb Callback
+			loadCallback("instagram-story-reply.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.IsReply()).To(BeTrue())
+			Expect(message.IsStoryReply()).To(BeTrue())
+			Expect(message.ReplyTo.Story.Id).To(Equal("17849000000000000"))
+
+			url, ok := message.RepliedStoryURL()
+			Expect(ok).To(BeTrue())
+			Expect(url).To(Equal("https://lookaside.fbsbx.com/ig_messaging_cdn/?asset_id=17849000000000000"))
+		})
+
+		It("should not report a message that is not a reply as one", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			Expect(cb.Entries[0].Messaging[0].Message.IsReply()).To(BeFalse())
+		})
+
+		It("should recognize every size of like sticker", func() {
+			for _, id := range []int64{369239263222822, 369239343222814, 369239383222810} {
+				message := &CallbackMessage{StickerId: id}
+
+				Expect(message.IsLike()).To(BeTrue())
+			}
+		})
+
+		It("should not report a text message as a sticker", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.IsSticker()).To(BeFalse())
+			Expect(message.IsLike()).To(BeFalse())
+		})
+	})
+
+	Describe("Message Helpers", func() {
+		It("should report a text only message", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.HasText()).To(BeTrue())
+			Expect(message.HasAttachments()).To(BeFalse())
+			Expect(message.IsTextOnly()).To(BeTrue())
+			Expect(message.IsAttachmentOnly()).To(BeFalse())
+			Expect(message.TextOrEmpty()).To(Equal("hello, world!"))
+		})
+
+		It("should report an attachment only message", func() {
+			var cb Callback
+			loadCallback("message-with-image-attachment.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.HasText()).To(BeFalse())
+			Expect(message.HasAttachments()).To(BeTrue())
+			Expect(message.IsTextOnly()).To(BeFalse())
+			Expect(message.IsAttachmentOnly()).To(BeTrue())
+			Expect(message.TextOrEmpty()).To(Equal(""))
+		})
+
+		It("should report neither text only nor attachment only when both are set", func() {
+			message := &CallbackMessage{
+				Text:        "hello, world!",
+				Attachments: []*CallbackAttachment{&CallbackAttachment{Type: "image"}},
+			}
+
+			Expect(message.IsTextOnly()).To(BeFalse())
+			Expect(message.IsAttachmentOnly()).To(BeFalse())
+		})
+
+		It("should return the id and attachment count of a message", func() {
+			var cb Callback
+			loadCallback("message-with-image-attachment.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.ID()).To(Equal(message.MessageId))
+			Expect(message.AttachmentCount()).To(Equal(1))
+		})
+
+		It("should report the echo of a sent message", func() {
+			message := &CallbackMessage{MessageId: "mid.1457764197618:41d102a3e1ae206a38", IsEcho: true}
+
+			Expect(message.IsEchoOf("mid.1457764197618:41d102a3e1ae206a38")).To(BeTrue())
+			Expect(message.IsEchoOf("mid.other")).To(BeFalse())
+
+			message.IsEcho = false
+			Expect(message.IsEchoOf("mid.1457764197618:41d102a3e1ae206a38")).To(BeFalse())
+		})
+
+		It("should return empty text for a nil message", func() {
+			var message *CallbackMessage
+
+			Expect(message.TextOrEmpty()).To(Equal(""))
+		})
+	})
+
+	Describe("Timestamps", func() {
+		It("should parse a millisecond timestamp as UTC", func() {
+			t := ParseTimestamp(1458668856451)
+
+			Expect(t).To(Equal(time.Date(2016, time.March, 22, 17, 47, 36, 451000000, time.UTC)))
+			Expect(t.Location()).To(Equal(time.UTC))
+		})
+
+		It("should format a time as a millisecond timestamp", func() {
+			t := time.Date(2016, time.March, 22, 17, 47, 36, 451000000, time.UTC)
+
+			Expect(FormatTimestamp(t)).To(Equal(int64(1458668856451)))
+			Expect(ParseTimestamp(FormatTimestamp(t))).To(Equal(t))
+		})
+
+		It("should map zero timestamps to the zero time and back", func() {
+			Expect(ParseTimestamp(0).IsZero()).To(BeTrue())
+			Expect(FormatTimestamp(time.Time{})).To(Equal(int64(0)))
+		})
+	})
+
+	Describe("Multiple Entries", func() {
+		It("should flatten messaging entries and attribute each to its page", func() {
+			var cb Callback
+			loadCallback("multiple-entries.json", &cb)
+
+			Expect(cb.EntryCount()).To(Equal(2))
+
+			messaging := cb.FlattenMessaging()
+			Expect(messaging).To(HaveLen(2))
+			Expect(messaging[0].Message.Text).To(Equal("hello, world!"))
+			Expect(messaging[0].PageID()).To(Equal("PAGE_ID"))
+			Expect(messaging[1].Postback.Payload).To(Equal("USER_DEFINED_PAYLOAD"))
+			Expect(messaging[1].PageID()).To(Equal("OTHER_PAGE_ID"))
+		})
+
+		It("should return no messaging entries for an empty callback", func() {
+			cb := &Callback{}
+
+			Expect(cb.EntryCount()).To(Equal(0))
+			Expect(cb.FlattenMessaging()).To(BeEmpty())
+		})
+
+		It("should call a function with each messaging entry and its page", func() {
+			var cb Callback
+			loadCallback("multiple-entries.json", &cb)
+
+			var pageIds []string
+			cb.ForEach(func(pageId string, entry *MessagingEntry) {
+				pageIds = append(pageIds, pageId)
+			})
+			Expect(pageIds).To(Equal([]string{"PAGE_ID", "OTHER_PAGE_ID"}))
+
+			var texts []string
+			cb.ForEachMessage(func(pageId string, entry *MessagingEntry) {
+				texts = append(texts, entry.Message.Text)
+			})
+			Expect(texts).To(Equal([]string{"hello, world!"}))
+		})
+
+		It("should filter messaging entries", func() {
+			var cb Callback
+			loadCallback("multiple-entries.json", &cb)
+
+			postbacks := cb.Filter(func(entry *MessagingEntry) bool {
+				return entry.Postback != nil
+			})
+
+			Expect(postbacks).To(HaveLen(1))
+			Expect(postbacks[0].PageID()).To(Equal("OTHER_PAGE_ID"))
+		})
+
+		It("should return the ids of the pages of the entries without duplicates", func() {
+			var cb Callback
+			loadCallback("multiple-entries.json", &cb)
+			cb.Entries = append(cb.Entries, &Entry{PageId: "PAGE_ID"})
+
+			Expect(cb.PageIDs()).To(Equal([]string{"PAGE_ID", "OTHER_PAGE_ID"}))
+
+			_, ok := cb.PageID()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should return the id of the page when all entries are for one page", func() {
+			cb := &Callback{Entries: []*Entry{&Entry{PageId: "PAGE_ID"}, &Entry{PageId: "PAGE_ID"}}}
+
+			pageId, ok := cb.PageID()
+			Expect(ok).To(BeTrue())
+			Expect(pageId).To(Equal("PAGE_ID"))
+		})
+	})
+
+	Describe("Entry Accessors", func() {
+		It("should return the page id and time", func() {
+			var cb Callback
+			loadCallback("delivery.json", &cb)
+
+			entry := cb.Entries[0]
+			Expect(entry.PageID()).To(Equal("PAGE_ID"))
+			Expect(entry.At()).To(Equal(time.Date(2016, time.March, 22, 17, 47, 36, 451000000, time.UTC)))
+			Expect(entry.HasMessaging()).To(BeTrue())
+		})
+
+		It("should return the first entry and messaging entry when there are any", func() {
+			messagingEntry := &MessagingEntry{Sender: Principal{Id: "USER_ID"}}
+
+			for _, c := range []struct {
+				callback       *Callback
+				hasEntry       bool
+				hasMessaging   bool
+				entryMessaging bool
+			}{
+				{&Callback{}, false, false, false},
+				{&Callback{Entries: []*Entry{{PageId: "PAGE_ID"}}}, true, false, false},
+				{&Callback{Entries: []*Entry{{PageId: "PAGE_ID", Messaging: []*MessagingEntry{messagingEntry}}}}, true, true, true},
+			} {
+				entry, ok := c.callback.FirstEntry()
+				Expect(ok).To(Equal(c.hasEntry))
+
+				first, ok := c.callback.FirstMessaging()
+				Expect(ok).To(Equal(c.hasMessaging))
+
+				if c.hasEntry {
+					_, ok = entry.FirstMessaging()
+					Expect(ok).To(Equal(c.entryMessaging))
+				}
+
+				if c.hasMessaging {
+					Expect(first).To(BeIdenticalTo(messagingEntry))
+				}
+			}
+		})
+
+		It("should attribute messaging entries to their page when decoding a callback", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			first, ok := cb.Entries[0].FirstMessaging()
+			Expect(ok).To(BeTrue())
+			Expect(first.PageID()).To(Equal("PAGE_ID"))
+		})
+
+		It("should skip nil entries when flattening a callback", func() {
+			messagingEntry := &MessagingEntry{}
+			cb := &Callback{Entries: []*Entry{nil, {Messaging: []*MessagingEntry{nil, messagingEntry}}}}
+
+			Expect(cb.FlattenMessaging()).To(Equal([]*MessagingEntry{messagingEntry}))
+		})
+
+		It("should return the zero time when there is no time", func() {
+			entry := &Entry{}
+
+			Expect(entry.At()).To(Equal(time.Time{}))
+			Expect(entry.HasMessaging()).To(BeFalse())
+		})
+	})
+
+	Describe("Messaging Entry Accessors", func() {
+		It("should return the sender and recipient ids", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.SenderID()).To(Equal("USER_ID"))
+			Expect(entry.RecipientID()).To(Equal("PAGE_ID"))
+			Expect(UserID(entry)).To(Equal("USER_ID"))
+			Expect(fmt.Sprint(entry.Sender)).To(Equal("psid:USER_ID"))
+		})
+
+		It("should tell the page from the user", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.Recipient.IsPage("OTHER_PAGE_ID", "PAGE_ID")).To(BeTrue())
+			Expect(entry.Recipient.IsUser("OTHER_PAGE_ID", "PAGE_ID")).To(BeFalse())
+			Expect(entry.Sender.IsUser("PAGE_ID")).To(BeTrue())
+			Expect(entry.Sender.IsPage()).To(BeFalse())
+			Expect(entry.Sender.IsUser()).To(BeTrue())
+			Expect(entry.Sender.Equals(Principal{Id: "USER_ID", IgId: "IG_ID"})).To(BeTrue())
+			Expect(entry.Sender.Equals(entry.Recipient)).To(BeFalse())
+		})
+
+		It("should convert the timestamp to a time", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.At()).To(Equal(time.Date(2016, time.March, 12, 6, 29, 57, 627000000, time.UTC)))
+		})
+
+		It("should return the zero time when there is no timestamp", func() {
+			entry := &MessagingEntry{}
+
+			Expect(entry.At().IsZero()).To(BeTrue())
+		})
+
+		It("should return the conversation of the entry", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			ref := cb.Entries[0].Messaging[0].ConversationRef()
+			Expect(ref).To(Equal(ConversationRef{ThreadId: "USER_ID", PageId: "PAGE_ID"}))
+			Expect(ref.ConversationKey()).To(Equal("PAGE_ID:USER_ID"))
+		})
+
+		It("should return the same conversation for a message echo", func() {
+			entry := &MessagingEntry{
+				Sender:    Principal{Id: "PAGE_ID"},
+				Recipient: Principal{Id: "USER_ID"},
+				Message:   &CallbackMessage{IsEcho: true},
+			}
+
+			Expect(entry.ConversationRef().ConversationKey()).To(Equal("PAGE_ID:USER_ID"))
+		})
+
+		It("should report the roles of apps for a handover event", func() {
+			event := &HandoverEvent{
+				Entry:    &MessagingEntry{Sender: Principal{Id: "USER_ID"}},
+				AppRoles: map[string][]string{"123456789": {"primary_receiver"}},
+			}
+
+			Expect(event.HasRole("123456789", AppRolePrimaryReceiver)).To(BeTrue())
+			Expect(event.HasRole("123456789", AppRoleSecondaryReceiver)).To(BeFalse())
+			Expect(event.HasRole("987654321", AppRolePrimaryReceiver)).To(BeFalse())
 		})
 	})
 
@@ -61,6 +466,82 @@ var _ = Describe("Callback Models", func() {
 			Expect(len(cb.Entries[0].Messaging[0].Delivery.MessageIds)).To(Equal(1))
 			Expect(cb.Entries[0].Messaging[0].Delivery.MessageIds[0]).To(Equal("mid.1458668856218:ed81099e15d3f4f233"))
 		})
+
+		It("should convert the watermark to a time", func() {
+			var cb Callback
+			loadCallback("delivery.json", &cb)
+
+			delivery := cb.Entries[0].Messaging[0].Delivery
+			Expect(delivery.WatermarkTime()).To(Equal(time.Date(2016, time.March, 22, 17, 47, 36, 253000000, time.UTC)))
+			Expect(delivery.DeliveredMessages()).To(Equal([]string{"mid.1458668856218:ed81099e15d3f4f233"}))
+		})
+
+		It("should unmarshal a watermark too large for 32 bits", func() {
+			var delivery Delivery
+			err := json.Unmarshal([]byte(`{"mids":[],"watermark":4102444800000,"seq":37}`), &delivery)
+
+			Expect(err).To(BeNil())
+			Expect(delivery.Watermark).To(Equal(int64(4102444800000)))
+			Expect(delivery.WatermarkTime()).To(Equal(time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Describe("Read Model", func() {
+		It("should unmarshal a read callback", func() {
+			var cb Callback
+			loadCallback("read.json", &cb)
+
+			read, ok := cb.Entries[0].Messaging[0].ReadEvent()
+			Expect(ok).To(BeTrue())
+			Expect(read.IndicatesAllRead()).To(BeTrue())
+			Expect(read.WatermarkTime()).To(Equal(time.Date(2016, time.March, 22, 17, 47, 36, 253000000, time.UTC)))
+		})
+
+		It("should not return a read event for other entries", func() {
+			var cb Callback
+			loadCallback("delivery.json", &cb)
+
+			_, ok := cb.Entries[0].Messaging[0].ReadEvent()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should not indicate all read without a watermark", func() {
+			Expect((&Read{}).IndicatesAllRead()).To(BeFalse())
+		})
+
+		It("should order reads by watermark", func() {
+			reads := []Read{{Watermark: 300}, {Watermark: 100}, {Watermark: 200}}
+
+			sort.Slice(reads, func(i, j int) bool {
+				return reads[j].IsAfter(reads[i])
+			})
+
+			Expect(reads).To(Equal([]Read{{Watermark: 100}, {Watermark: 200}, {Watermark: 300}}))
+		})
+	})
+
+	Describe("Messaging Feedback Model", func() {
+		It("should parse the answers to a customer feedback template", func() {
+			var cb Callback
+			loadCallback("messaging-feedback.json", &cb)
+
+			feedback, err := ParseFeedbackCallback(&cb)
+
+			Expect(err).To(BeNil())
+			answer := feedback.FeedbackScreens[0].Questions["hauydmns8"]
+			Expect(answer.Type).To(Equal("csat"))
+			Expect(answer.Payload).To(Equal("4"))
+			Expect(answer.FollowUp.Payload).To(Equal("Good service!"))
+		})
+
+		It("should return an error for a callback without feedback", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			_, err := ParseFeedbackCallback(&cb)
+
+			Expect(err).To(MatchError("callback has no messaging feedback"))
+		})
 	})
 
 	Describe("Postback Model", func() {
@@ -69,6 +550,167 @@ var _ = Describe("Callback Models", func() {
 			loadCallback("postback.json", &cb)
 			Expect(cb.Entries[0].Messaging[0].Postback.Payload).To(Equal("USER_DEFINED_PAYLOAD"))
 		})
+
+		It("should unmarshal a postback referral separately from the entry referral", func() {
+			var cb Callback
+			loadCallback("postback-with-referral.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.Postback.Referral.Ref).To(Equal("GET_STARTED_REF"))
+			Expect(entry.Postback.Referral.Source).To(Equal("SHORTLINK"))
+
+			Expect(entry.IsReferral()).To(BeTrue())
+			Expect(entry.Referral.Source).To(Equal("ADS"))
+			Expect(entry.Referral.AdId).To(Equal("6045246247433"))
+			Expect(entry.Referral.AdsContextData.AdTitle).To(Equal("Peter's Hats"))
+			Expect(entry.Referral.AdsContextData.PostId).To(Equal("6045246247433"))
+			Expect(entry.Referral.RefererURI).To(Equal("https://m.me/PAGE_ID?ref=AD_REF"))
+		})
+
+		It("should decode a payload encoded with NewPostbackPayload", func() {
+			type order struct {
+				Action    string `json:"action"`
+				ProductId int    `json:"productId"`
+			}
+
+			payload, err := NewPostbackPayload(&order{Action: "buy", ProductId: 42})
+			Expect(err).To(BeNil())
+			Expect(payload).To(Equal(`{"action":"buy","productId":42}`))
+
+			postback := &Postback{Payload: PostbackButton("Buy", payload).Payload}
+
+			var decoded order
+			Expect(postback.DecodePayload(&decoded)).To(Succeed())
+			Expect(decoded).To(Equal(order{Action: "buy", ProductId: 42}))
+		})
+
+		It("should return an error decoding a payload that is not json", func() {
+			var cb Callback
+			loadCallback("postback.json", &cb)
+
+			postback := cb.Entries[0].Messaging[0].Postback
+			Expect(postback.PayloadString()).To(Equal("USER_DEFINED_PAYLOAD"))
+
+			var decoded map[string]interface{}
+			Expect(postback.DecodePayload(&decoded)).NotTo(Succeed())
+		})
+
+		It("should not report a postback without a referral as a referral", func() {
+			var cb Callback
+			loadCallback("postback.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.IsReferral()).To(BeFalse())
+			Expect(entry.Postback.Referral).To(BeNil())
+		})
+	})
+
+	Describe("Reaction Model", func() {
+		It("should unmarshal a reaction callback", func() {
+			var cb Callback
+			loadCallback("reaction.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.IsReaction()).To(BeTrue())
+			Expect(entry.Reaction.Action).To(Equal(ReactionActionReact))
+			Expect(entry.Reaction.ReactionType).To(Equal(ReactionLove))
+			Expect(entry.Reaction.Emoji).To(Equal("\u2764"))
+			Expect(entry.Reaction.MessageId).To(Equal("mid.1458696618141:b4ef9d19ec21086067"))
+		})
+
+		It("should not report other callbacks as reactions", func() {
+			var cb Callback
+			loadCallback("postback.json", &cb)
+
+			Expect(cb.Entries[0].Messaging[0].IsReaction()).To(BeFalse())
+		})
+	})
+
+	Describe("Platform", func() {
+		It("should identify a callback from a page as Messenger", func() {
+			var cb Callback
+			loadCallback("text-message.json", &cb)
+
+			Expect(cb.Platform()).To(Equal(PlatformMessenger))
+			Expect(cb.Entries[0].Platform()).To(Equal(PlatformMessenger))
+		})
+
+		It("should unmarshal an Instagram callback", func() {
+			var cb Callback
+			loadCallback("instagram-message.json", &cb)
+
+			Expect(cb.Platform()).To(Equal(PlatformInstagram))
+			Expect(cb.Entries[0].Platform()).To(Equal(PlatformInstagram))
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.Sender.Id).To(Equal("IGSID"))
+			Expect(entry.Recipient.IgId).To(Equal("17841405309211844"))
+			Expect(entry.Message.Text).To(Equal("hello, world!"))
+			Expect(entry.Message.IsStoryMention()).To(BeFalse())
+		})
+
+		It("should unmarshal an Instagram story mention", func() {
+			var cb Callback
+			loadCallback("instagram-story-mention.json", &cb)
+
+			message := cb.Entries[0].Messaging[0].Message
+			Expect(message.IsStoryMention()).To(BeTrue())
+			Expect(message.StoryMention.Id).To(Equal("17849000000000000"))
+			Expect(message.StoryMention.Link).To(Equal("https://lookaside.fbsbx.com/ig_messaging_cdn/?asset_id=17849000000000000"))
+		})
+
+		It("should map callback objects to platforms", func() {
+			Expect(PlatformFromObject("page")).To(Equal(PlatformMessenger))
+			Expect(PlatformFromObject("instagram")).To(Equal(PlatformInstagram))
+			Expect(PlatformFromObject("whatsapp_business_account")).To(Equal(PlatformWhatsApp))
+			Expect(PlatformFromObject("user")).To(Equal(Platform("")))
+		})
+	})
+
+	Describe("Message Delete Model", func() {
+		It("should unmarshal a message delete callback", func() {
+			var cb Callback
+			loadCallback("message-delete.json", &cb)
+
+			entry := cb.Entries[0].Messaging[0]
+			Expect(entry.IsMessageDelete()).To(BeTrue())
+			Expect(entry.MessageDelete.MessageId).To(Equal("m_AG5Hz2Uq7tuwNEhXfYYKj8mJEM_QPpz5jdCK48PnKAjSdjfipqxqMvK8ma6AC8fplwlqLP_5cgXIbu7I3rBN0P"))
+		})
+	})
+
+	Describe("One-Time Notification Opt In Model", func() {
+		It("should unmarshal a one-time notification opt in", func() {
+			var cb Callback
+			loadCallback("one-time-notification-optin.json", &cb)
+
+			optIn := cb.Entries[0].Messaging[0].OptIn
+			Expect(optIn.Type).To(Equal("one_time_notif_req"))
+			Expect(optIn.Payload).To(Equal("HAT_RESTOCK"))
+			Expect(optIn.OneTimeNotifToken).To(Equal("7614129489361658244"))
+			Expect(optIn.IsOneTimeNotification()).To(BeTrue())
+			Expect(optIn.IsCheckboxPlugin()).To(BeFalse())
+		})
+	})
+
+	Describe("Checkbox Plugin Opt In Model", func() {
+		It("should unmarshal a checkbox plugin opt in and decode its ref", func() {
+			var cb Callback
+			loadCallback("checkbox-plugin-optin.json", &cb)
+
+			optIn := cb.Entries[0].Messaging[0].OptIn
+			Expect(optIn.UserRef).To(Equal("UNIQUE_REF_PARAM"))
+			Expect(optIn.IsCheckboxPlugin()).To(BeTrue())
+			Expect(optIn.IsOneTimeNotification()).To(BeFalse())
+
+			ref, err := optIn.RefBase64()
+			Expect(err).To(BeNil())
+
+			var decoded struct {
+				Campaign string `json:"campaign"`
+			}
+			Expect((&OptIn{Ref: string(ref)}).DecodeRef(&decoded)).To(Succeed())
+			Expect(decoded.Campaign).To(Equal("fall"))
+		})
 	})
 
 	Describe("Authentication Model", func() {
@@ -77,6 +719,26 @@ var _ = Describe("Callback Models", func() {
 			loadCallback("authentication.json", &cb)
 			Expect(cb.Entries[0].Messaging[0].OptIn.Ref).To(Equal("PASS_THROUGH_PARAM"))
 		})
+
+		It("should decode a ref encoded with EncodeOptInRef", func() {
+			type campaign struct {
+				Source string `json:"src"`
+			}
+
+			ref, err := EncodeOptInRef(&campaign{Source: "spring"})
+			Expect(err).To(BeNil())
+			Expect(ref).To(Equal(`{"src":"spring"}`))
+
+			var decoded campaign
+			Expect(DecodeOptInRef(ref, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(campaign{Source: "spring"}))
+		})
+
+		It("should not encode a ref over 50 characters", func() {
+			_, err := EncodeOptInRef(strings.Repeat("a", MaxOptInRefSize))
+
+			Expect(err).To(MatchError("OptIn.Ref is 52 characters, exceeding the limit of 50"))
+		})
 	})
 })
 
@@ -87,6 +749,36 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "text-message.json")
 	})
 
+	It("should marshal a send request with a text message created with MessageTo", func() {
+		expectCorrectMarshaling(MessageTo("USER_ID", "Hello, world!"), "text-message.json")
+	})
+
+	It("should describe recipients without personal details", func() {
+		Expect(TextMessage("Hello").To("123456789").Recipient.String()).To(Equal("user:123456789"))
+		Expect(TextMessage("Hello").ToPhoneNumber("+1(212)555-2368").Recipient.String()).To(Equal("phone:+*******2368"))
+		Expect(Recipient{UserRef: "abcdefghijklmnop"}.String()).To(Equal("user_ref:abcdefgh..."))
+	})
+
+	It("should return the json of a request and its parts", func() {
+		sendRequest := ButtonTemplateMessage("What do you want to do next?", URLButton("Show Website", "https://petersapparel.parseapp.com")).To("USER_ID")
+
+		requestJSON, err := sendRequest.ToJSON()
+		Expect(err).To(BeNil())
+		Expect(requestJSON).To(MatchJSON(`{"recipient":{"id":"USER_ID"},"message":{"attachment":{"type":"template","payload":{"template_type":"button","text":"What do you want to do next?","buttons":[{"type":"web_url","title":"Show Website","url":"https://petersapparel.parseapp.com"}]}}}}`))
+
+		messageJSON, err := sendRequest.Message.ToJSON()
+		Expect(err).To(BeNil())
+		Expect(messageJSON).To(MatchJSON(`{"attachment":{"type":"template","payload":{"template_type":"button","text":"What do you want to do next?","buttons":[{"type":"web_url","title":"Show Website","url":"https://petersapparel.parseapp.com"}]}}}`))
+
+		attachmentJSON, err := sendRequest.Message.Attachment.ToJSON()
+		Expect(err).To(BeNil())
+		Expect(attachmentJSON).To(MatchJSON(`{"type":"template","payload":{"template_type":"button","text":"What do you want to do next?","buttons":[{"type":"web_url","title":"Show Website","url":"https://petersapparel.parseapp.com"}]}}`))
+
+		buttonJSON, err := URLButton("Show Website", "https://petersapparel.parseapp.com").ToJSON()
+		Expect(err).To(BeNil())
+		Expect(buttonJSON).To(MatchJSON(`{"type":"web_url","title":"Show Website","url":"https://petersapparel.parseapp.com"}`))
+	})
+
 	It("should marshal a send request with text quick replies", func() {
 		everything := TextReply("Everything", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_EVERYTHING")
 		nothing := TextReply("Nothing", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_NOTHING")
@@ -95,6 +787,15 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "text-message-with-text-quick-replies.json")
 	})
 
+	It("should marshal a send request with text quick replies created in one call", func() {
+		sendRequest := TextMessageWithReplies("What do you want?",
+			TextReply("Everything", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_EVERYTHING"),
+			TextReply("Nothing", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_NOTHING")).
+			To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "text-message-with-text-quick-replies.json")
+	})
+
 	It("should marshal a send request with text and image quick replies", func() {
 		everything := TextReplyWithImage("Everything", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_EVERYTHING", "http://fake.com/everything.png")
 		nothing := TextReplyWithImage("Nothing", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_NOTHING", "http://fake.com/nothing.png")
@@ -103,6 +804,102 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "text-message-with-text-and-image-quick-replies.json")
 	})
 
+	It("should add quick replies in separate calls", func() {
+		sendRequest := TextMessage("Pick a color:").
+			WithQuickReplies(TextReply("Red", "PICKED_RED"), TextReply("Green", "PICKED_GREEN")).
+			WithQuickReplies(TextReply("Blue", "PICKED_BLUE"))
+
+		Expect(sendRequest.Message.QuickReplies).To(HaveLen(3))
+		Expect(sendRequest.Message.QuickReplies[2].Title).To(Equal("Blue"))
+	})
+
+	It("should clear quick replies", func() {
+		sendRequest := TextMessage("Pick a color:").
+			WithQuickReplies(TextReply("Red", "PICKED_RED")).
+			ClearQuickReplies()
+
+		Expect(sendRequest.Message.QuickReplies).To(BeEmpty())
+	})
+
+	It("should marshal a send request with each type of button", func() {
+		sendRequest := ButtonTemplateMessage("What do you want to do next?",
+			CallButton("Call Representative", "+16505551234"),
+			ShareButton(),
+			LoginButton("https://www.example.com/authorize"),
+		).To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-call-share-and-login-buttons.json")
+	})
+
+	It("should create logout and game play buttons", func() {
+		Expect(LogoutButton()).To(Equal(&Button{Type: "account_unlink"}))
+		Expect(GamePlayButton("Play", "PAYLOAD")).To(Equal(&Button{Type: "game_play", Title: "Play", Payload: "PAYLOAD"}))
+	})
+
+	It("should marshal a send request with a template this package has no helper for", func() {
+		sendRequest := TemplateMessage(customerFeedbackPayload{
+			TemplateType: "customer_feedback",
+			Title:        "Rate your experience",
+		})
+
+		sendBytes, err := json.Marshal(sendRequest.Message)
+		if err != nil {
+			Fail(fmt.Sprintf("Error marshaling value: %v", err))
+		}
+
+		Expect(string(sendBytes)).To(Equal(`{"attachment":{"type":"template","payload":{"template_type":"customer_feedback","title":"Rate your experience"}}}`))
+	})
+
+	It("should report the template type of the template helpers", func() {
+		payload := ButtonTemplateMessage("What do you want to do next?").Message.Attachment.Payload
+
+		Expect(payload.(TemplateTyper).GetTemplateType()).To(Equal("button"))
+	})
+
+	It("should marshal a send request with a customer feedback template", func() {
+		sendRequest := CustomerFeedbackMessage(CustomerFeedbackPayload{
+			Title:       "Rate your experience with Peter's Hats.",
+			Subtitle:    "Let Peter's Hats know how they are doing by answering two questions",
+			ButtonTitle: "Rate Experience",
+			FeedbackScreens: []*FeedbackScreen{
+				&FeedbackScreen{
+					Questions: []*FeedbackQuestion{
+						&FeedbackQuestion{
+							Id:          "hauydmns8",
+							Type:        "csat",
+							Title:       "How would you rate your experience with Peter's Hats?",
+							ScoreLabel:  "neg_pos",
+							ScoreOption: "five_stars",
+							FollowUp: &FeedbackFollowUp{
+								Type:        "free_form",
+								Placeholder: "Give additional feedback",
+							},
+						},
+					},
+				},
+			},
+			BusinessPrivacy: &BusinessPrivacy{URL: "https://petersapparel.parseapp.com/privacy"},
+			ExpiresInDays:   3,
+		}).To("USER_ID")
+
+		Expect(sendRequest.Validate()).To(BeNil())
+		expectCorrectMarshaling(sendRequest, "message-with-customer-feedback-template.json")
+	})
+
+	It("should marshal a request turning the typing indicator on", func() {
+		request := NewTypingOnRequest("USER_ID")
+
+		Expect(request.Validate()).To(BeNil())
+		expectCorrectMarshaling(request, "sender-action-typing-on.json")
+	})
+
+	It("should marshal a request marking the last message as seen", func() {
+		request := NewMarkSeenRequest("USER_ID")
+
+		Expect(request.Validate()).To(BeNil())
+		expectCorrectMarshaling(request, "sender-action-mark-seen.json")
+	})
+
 	It("should marshal a send request with a location quick reply", func() {
 		sendRequest := TextMessage("Where are you?").WithQuickReplies(LocationReply()).To("USER_ID")
 
@@ -115,6 +912,33 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "message-with-image-attachment.json")
 	})
 
+	It("should marshal a message composed with an attachment", func() {
+		sendRequest := (&SendRequest{Message: Message{Attachment: ImageAttachment("IMAGE_URL")}}).To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-image-attachment.json")
+	})
+
+	It("should marshal a message body with quick replies", func() {
+		message, err := NewQuickReplyMessageBody("Pick a color:", []*QuickReply{TextReply("Red", "DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_RED")})
+
+		Expect(err).To(BeNil())
+		Expect(message.ToJSON()).To(MatchJSON(`{"text":"Pick a color:","quick_replies":[{"content_type":"text","title":"Red","payload":"DEVELOPER_DEFINED_PAYLOAD_FOR_PICKING_RED"}]}`))
+		Expect(NewAttachmentMessageBody(ImageAttachment("IMAGE_URL")).Attachment.Type).To(Equal("image"))
+	})
+
+	It("should marshal an attachment of a resource uploaded before", func() {
+		attachmentJSON, err := ReusableAttachment("image", "1857777774821032").ToJSON()
+
+		Expect(err).To(BeNil())
+		Expect(string(attachmentJSON)).To(Equal(`{"type":"image","payload":{"attachment_id":"1857777774821032"}}`))
+	})
+
+	It("should marshal a send request with a reusable image attached", func() {
+		sendRequest := ImageMessageWithOptions("IMAGE_URL", ImageAttachmentOptions{IsReusable: true}).To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-reusable-image-attachment.json")
+	})
+
 	It("should marshal an a message with an image attached by uploading the image", func() {
 		imageBytes, err := ioutil.ReadFile("./sample-send-api-data/fb-logo.png")
 		if err != nil {
@@ -135,6 +959,15 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "message-with-button-attachment.json")
 	})
 
+	It("should marshal a send request with a button attachment created with ButtonMessage", func() {
+		sendRequest := ButtonMessage("What do you want to do next?",
+			URLButton("Show Website", "https://petersapparel.parseapp.com"),
+			PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD")).
+			To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-button-attachment.json")
+	})
+
 	It("should marshal a send request with a generic attachment", func() {
 		viewWebsite := URLButton("View Website", "https://petersapparel.parseapp.com/view_item?item_id=100")
 
@@ -152,6 +985,14 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "message-with-generic-template-attachment.json")
 	})
 
+	It("should set the receipt timestamp from the order date", func() {
+		payload := NewReceiptPayload("Stephane Crozatier", "12345678902", "USD", "Visa 2345").
+			SetOrderDate(time.Date(2015, time.April, 7, 22, 14, 12, 0, time.UTC))
+
+		Expect(payload.TemplateType).To(Equal(TemplateTypeReceipt))
+		Expect(payload.Timestamp).To(Equal(int64(1428444852)))
+	})
+
 	It("should marshal a send request with a receipt attachment", func() {
 		header := &ReceiptHeader{
 			RecipientName: "Stephane Crozatier",
@@ -159,7 +1000,7 @@ var _ = Describe("Send API Models", func() {
 			Currency:      "USD",
 			PaymentMethod: "Visa 2345",
 			OrderURL:      "http://petersapparel.parseapp.com/order?order_id=123456",
-			Timestamp:     "1428444852",
+			Timestamp:     1428444852,
 		}
 
 		summary := &ReceiptSummary{
@@ -213,12 +1054,275 @@ var _ = Describe("Send API Models", func() {
 		expectCorrectMarshaling(sendRequest, "message-with-receipt-attachment.json")
 	})
 
+	It("should marshal flight info", func() {
+		flightInfo := &FlightInfo{
+			ConnectionId: "c001",
+			SegmentId:    "s001",
+			FlightNumber: "KL9123",
+			AircraftType: "Boeing 737",
+			DepartureAirport: AirportInfo{
+				AirportCode: "SFO",
+				City:        "San Francisco",
+				Terminal:    "T4",
+				Gate:        "G8",
+			},
+			ArrivalAirport: AirportInfo{
+				AirportCode: "SLC",
+				City:        "Salt Lake City",
+			},
+			FlightSchedule: FlightSchedule{
+				BoardingTime:  "2016-01-02T18:30",
+				DepartureTime: "2016-01-02T19:45",
+				ArrivalTime:   "2016-01-02T21:20",
+			},
+			TravelClass: "business",
+		}
+
+		expectCorrectMarshaling(flightInfo, "airline-flight-info.json")
+	})
+
+	It("should marshal passenger info", func() {
+		passengerInfo := &PassengerInfo{
+			PassengerId:  "p001",
+			TicketNumber: "0741234567890",
+			Name:         "Farbound Smith Jr",
+		}
+
+		passengerSegmentInfo := &PassengerSegmentInfo{
+			SegmentId:   "s001",
+			PassengerId: "p001",
+			Seat:        "12A",
+			SeatType:    "Business",
+			ProductInfo: []*ProductInfo{
+				&ProductInfo{Title: "Lounge", Value: "Complimentary lounge access"},
+				&ProductInfo{Title: "Baggage", Value: "1 extra bag 50lbs"},
+			},
+		}
+
+		priceInfo := &PriceInfo{
+			Title:    "Fuel surcharge",
+			Amount:   "1597",
+			Currency: "USD",
+		}
+
+		expectCorrectMarshaling(passengerInfo, "airline-passenger-info.json")
+		expectCorrectMarshaling(passengerSegmentInfo, "airline-passenger-segment-info.json")
+		expectCorrectMarshaling(priceInfo, "airline-price-info.json")
+	})
+
+	It("should marshal a send request with a one-time notification request", func() {
+		sendRequest := OneTimeNotificationRequestMessage("Notify me when the hat is back in stock", "HAT_RESTOCK").To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-one-time-notification-request.json")
+	})
+
+	Describe("Equality", func() {
+		It("should treat requests built in a different order as equal", func() {
+			a := GenericTemplateMessage(&GenericElement{Title: "Welcome to Peter's Hats"}).To("USER_ID").WithTag(TagPostPurchaseUpdate)
+			b := GenericTemplateMessage(&GenericElement{Title: "Welcome to Peter's Hats"}).WithTag(TagPostPurchaseUpdate).To("USER_ID")
+
+			Expect(a.Equal(b)).To(BeTrue())
+			Expect(a.Hash()).To(Equal(b.Hash()))
+		})
+
+		It("should ignore the correlation id", func() {
+			a := TextMessage("hello, world!").To("USER_ID").WithCorrelationId("1")
+			b := TextMessage("hello, world!").To("USER_ID").WithCorrelationId("2")
+
+			Expect(a.Equal(b)).To(BeTrue())
+			Expect(a.Hash()).To(Equal(b.Hash()))
+		})
+
+		It("should ignore the typing delay", func() {
+			a := TextMessage("hello, world!").To("USER_ID").TypingBefore(time.Second)
+			b := TextMessage("hello, world!").To("USER_ID")
+
+			Expect(a.Equal(b)).To(BeTrue())
+			Expect(a.Hash()).To(Equal(b.Hash()))
+		})
+
+		It("should distinguish requests to different recipients", func() {
+			a := TextMessage("hello, world!").To("USER_ID")
+			b := TextMessage("hello, world!").To("OTHER_USER_ID")
+
+			Expect(a.Equal(b)).To(BeFalse())
+			Expect(a.Hash()).ToNot(Equal(b.Hash()))
+		})
+
+		It("should distinguish requests with different attachment payloads", func() {
+			a := GenericTemplateMessage(&GenericElement{Title: "Welcome to Peter's Hats"}).To("USER_ID")
+			b := GenericTemplateMessage(&GenericElement{Title: "Welcome to Peter's Shoes"}).To("USER_ID")
+
+			Expect(a.Equal(b)).To(BeFalse())
+			Expect(a.Hash()).ToNot(Equal(b.Hash()))
+		})
+
+		It("should distinguish uploads with different data", func() {
+			a := ImageDataMessage([]byte{1, 2, 3}, "image/png").To("USER_ID")
+			b := ImageDataMessage([]byte{1, 2, 4}, "image/png").To("USER_ID")
+
+			Expect(a.Equal(b)).To(BeFalse())
+			Expect(a.Hash()).ToNot(Equal(b.Hash()))
+		})
+	})
+
+	It("should marshal a send request with an audio attachment", func() {
+		sendRequest := AudioMessage("https://petersapparel.com/bin/clip.mp3").To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-audio-attachment.json")
+	})
+
+	It("should marshal a send request with a list attachment", func() {
+		collection := &ListElement{
+			Title:    "Classic T-Shirt Collection",
+			Subtitle: "See all our colors",
+			ImageURL: "https://peterssendreceiveapp.ngrok.io/img/collection.png",
+			Buttons:  []*Button{URLButton("View", "https://peterssendreceiveapp.ngrok.io/collection")},
+		}
+
+		whiteShirt := &ListElement{
+			Title:    "Classic White T-Shirt",
+			Subtitle: "See all our colors",
+		}
+
+		sendRequest := ListTemplateMessage("compact", collection, whiteShirt).To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-list-template-attachment.json")
+		expectCorrectMarshaling(CompactListTemplateMessage(collection, whiteShirt).To("USER_ID"), "message-with-list-template-attachment.json")
+	})
+
+	It("should set the top element style of a large list template", func() {
+		sendRequest := LargeListTemplateMessage(&ListElement{Title: "Classic T-Shirt Collection"})
+
+		payload := sendRequest.Message.Attachment.Payload.(ListPayload)
+		Expect(payload.TopElementStyle).To(Equal(ListStyleLarge))
+	})
+
+	It("should marshal a send request with a media attachment", func() {
+		image := &MediaElement{
+			MediaType:    "image",
+			AttachmentId: "1854626884821032",
+			Buttons:      []*Button{URLButton("View Website", "https://petersapparel.parseapp.com")},
+		}
+
+		sendRequest := MediaTemplateMessage(image).To("USER_ID")
+
+		expectCorrectMarshaling(sendRequest, "message-with-media-template-attachment.json")
+	})
+
+	It("should support chaining every fluent method from a template constructor", func() {
+		welcome := &GenericElement{
+			Title:    "Welcome to Peter's Hats",
+			ImageURL: "http://petersapparel.parseapp.com/img/item100-thumb.png",
+			Subtitle: "We've got the right hat for everyone.",
+			Buttons:  []*Button{PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD")},
+		}
+
+		sendRequest := GenericTemplateMessage(welcome).
+			To("USER_ID").
+			NoPush().
+			WithTag(TagHumanAgent).
+			WithPersona("PERSONA_ID").
+			WithMetadata("DEVELOPER_DEFINED_METADATA")
+
+		Expect(sendRequest.MessagingType).To(Equal(MessagingTypeMessageTag))
+		expectCorrectMarshaling(sendRequest, "message-with-generic-template-attachment-and-tag.json")
+	})
+
+	It("should set the messaging type", func() {
+		sendRequest := VideoMessage("https://petersapparel.com/bin/clip.mp4").
+			ToPhoneNumber("+1(212)555-2368").
+			Regular().
+			WithMessagingType(MessagingTypeUpdate)
+
+		Expect(sendRequest.MessagingType).To(Equal(MessagingTypeUpdate))
+		Expect(sendRequest.Message.Attachment.Type).To(Equal("video"))
+	})
+
 	It("should marshal a send request to a phone number", func() {
-		sendRequest := TextMessage("Hello, world!").ToPhoneNumber("+1(212)555-2368")
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumberRaw("+1(212)555-2368")
 
 		expectCorrectMarshaling(sendRequest, "text-message-to-phone-number.json")
 	})
 
+	It("should normalize the phone number of a send request", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumber("+1(212)555-2368")
+
+		Expect(sendRequest.Recipient.PhoneNumber).To(Equal("+12125552368"))
+	})
+
+	It("should report a phone number that can't be normalized when validating", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumber("020 7946 0958")
+
+		Expect(sendRequest.Recipient.PhoneNumber).To(Equal("020 7946 0958"))
+		Expect(sendRequest.Validate()).To(MatchError(`Recipient.PhoneNumber: phone number "020 7946 0958" has no country code`))
+	})
+
+	It("should report a phone number with a name that can't be normalized when validating", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumberWithName("020 7946 0958", "John", "Doe")
+
+		Expect(sendRequest.Validate()).To(MatchError(`Recipient.PhoneNumber: phone number "020 7946 0958" has no country code`))
+	})
+
+	It("should not check a phone number set with ToPhoneNumberRaw", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumber("020 7946 0958").ToPhoneNumberRaw("+442079460958")
+
+		Expect(sendRequest.Validate()).To(BeNil())
+	})
+
+	Describe("Phone Numbers", func() {
+		It("should normalize US phone numbers", func() {
+			for _, raw := range []string{"555-123-4567", "(555) 123-4567", "555.123.4567", "1 555 123 4567", "+1 (555) 123-4567"} {
+				Expect(NormalizePhoneNumber(raw)).To(Equal("+15551234567"), raw)
+			}
+		})
+
+		It("should normalize UK phone numbers with a country code", func() {
+			for _, raw := range []string{"+44 20 7946 0958", "0044 20 7946 0958", "+44 (20) 7946-0958"} {
+				Expect(NormalizePhoneNumber(raw)).To(Equal("+442079460958"), raw)
+			}
+		})
+
+		It("should normalize international phone numbers", func() {
+			Expect(NormalizePhoneNumber("+49 30 901820")).To(Equal("+4930901820"))
+			Expect(NormalizePhoneNumber("+81 3-1234-5678")).To(Equal("+81312345678"))
+			Expect(NormalizePhoneNumber("+86 138 0013 8000")).To(Equal("+8613800138000"))
+		})
+
+		It("should reject a national number without a country code", func() {
+			_, err := NormalizePhoneNumber("020 7946 0958")
+
+			Expect(err).To(MatchError(`phone number "020 7946 0958" has no country code`))
+		})
+
+		It("should reject phone numbers with letters", func() {
+			_, err := NormalizePhoneNumber("1-800-FLOWERS")
+
+			Expect(err).To(MatchError(`phone number "1-800-FLOWERS" contains 'F', which is not a digit or separator`))
+		})
+
+		It("should reject phone numbers that are too short or too long", func() {
+			_, err := NormalizePhoneNumber("+1 234")
+			Expect(err).To(MatchError(`phone number "+1 234" is not a valid international phone number`))
+
+			_, err = NormalizePhoneNumber("+1234567890123456")
+			Expect(err).To(MatchError(`phone number "+1234567890123456" is not a valid international phone number`))
+		})
+	})
+
+	It("should marshal a send request to a phone number with a name", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumberWithNameRaw("+1(212)555-2368", "John", "Doe")
+
+		expectCorrectMarshaling(sendRequest, "text-message-to-phone-number-with-name.json")
+	})
+
+	It("should normalize the phone number of a send request with a name", func() {
+		sendRequest := TextMessage("Hello, world!").ToPhoneNumberWithName("+1(212)555-2368", "John", "Doe")
+
+		Expect(sendRequest.Recipient.PhoneNumber).To(Equal("+12125552368"))
+		Expect(sendRequest.Recipient.Name).To(Equal(&RecipientName{FirstName: "John", LastName: "Doe"}))
+	})
+
 	It("should marshal a send request with a REGULAR notification type", func() {
 		sendRequest := TextMessage("Hello, world!").To("USER_ID").Regular()
 
@@ -252,6 +1356,57 @@ var _ = Describe("Send API Models", func() {
 
 		Expect(response.Error.Message).To(Equal("Invalid parameter"))
 	})
+
+	It("should format an error with all of its fields", func() {
+		sendError := &SendError{
+			Message:   "Call to a subscribed app failed because the app is over capacity",
+			Type:      "OAuthException",
+			Code:      613,
+			ErrorData: "Too many calls",
+			FBTraceId: "AbCd123",
+		}
+
+		Expect(sendError.Error()).To(Equal("Facebook API error: [code 613] OAuthException: Call to a subscribed app failed because the app is over capacity (error_data: Too many calls) (fbtrace_id: AbCd123)"))
+	})
+
+	It("should wrap a sentinel error for the code of an error", func() {
+		for code, sentinel := range map[int]error{
+			2:    ErrAPIService,
+			613:  ErrRateLimited,
+			100:  ErrInvalidParameter,
+			190:  ErrAccessToken,
+			230:  ErrPermission,
+			551:  ErrUserUnavailable,
+			1200: ErrTemporarySendFailure,
+		} {
+			Expect(errors.Is(&SendError{Code: code}, sentinel)).To(BeTrue())
+		}
+
+		Expect((&SendError{Code: 12345}).Unwrap()).To(BeNil())
+	})
+})
+
+var _ = Describe("User Profile Models", func() {
+	It("should set the size of the avatar on the profile photo url", func() {
+		userProfile := &UserProfile{}
+		Expect(json.Unmarshal([]byte(loadUserProfileString("user-profile.json")), userProfile)).To(Succeed())
+
+		Expect(userProfile.HasAvatar()).To(BeTrue())
+		Expect(userProfile.AvatarURL(AvatarSizeSmall)).To(Equal(userProfile.ProfilePhotoURL + "?height=50&width=50"))
+	})
+
+	It("should keep the existing query of the profile photo url", func() {
+		userProfile := &UserProfile{ProfilePhotoURL: "https://platform-lookaside.fbsbx.com/platform/profilepic/?psid=USER_ID&width=1024"}
+
+		Expect(userProfile.AvatarURL(AvatarSizeLarge)).To(Equal("https://platform-lookaside.fbsbx.com/platform/profilepic/?height=200&psid=USER_ID&width=200"))
+	})
+
+	It("should return no avatar url for a profile without a photo", func() {
+		userProfile := &UserProfile{}
+
+		Expect(userProfile.HasAvatar()).To(BeFalse())
+		Expect(userProfile.AvatarURL(AvatarSizeMedium)).To(Equal(""))
+	})
 })
 
 func loadCallback(fileName string, cb *Callback) {
@@ -266,6 +1421,15 @@ func loadCallback(fileName string, cb *Callback) {
 	}
 }
 
+func loadCallbackString(fileName string) string {
+	fileBytes, err := ioutil.ReadFile("./sample-callback-data/" + fileName)
+	if err != nil {
+		Fail(fmt.Sprintf("Error reading file \"%v\": %v", fileName, err))
+	}
+
+	return string(fileBytes)
+}
+
 func loadSendRequestString(fileName string) string {
 	fileBytes, err := ioutil.ReadFile("./sample-send-api-data/" + fileName)
 	if err != nil {
@@ -275,6 +1439,15 @@ func loadSendRequestString(fileName string) string {
 	return makeOneLine(string(fileBytes))
 }
 
+type customerFeedbackPayload struct {
+	TemplateType string `json:"template_type"`
+	Title        string `json:"title"`
+}
+
+func (p customerFeedbackPayload) GetTemplateType() string {
+	return p.TemplateType
+}
+
 func expectCorrectMarshaling(v interface{}, fileName string) {
 	sendBytes, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
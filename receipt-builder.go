@@ -0,0 +1,105 @@
+package fbmessenger
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ReceiptBuilder builds a ReceiptPayload one part at a time, checking the parts fit together
+when Build is called. Each method returns the same ReceiptBuilder on which it is called to
+support method chaining.
+*/
+type ReceiptBuilder struct {
+	payload ReceiptPayload
+}
+
+// NewReceiptBuilder creates a ReceiptBuilder for a receipt with the required top level fields set.
+func NewReceiptBuilder(recipientName, orderNumber, currency, paymentMethod string) *ReceiptBuilder {
+	return &ReceiptBuilder{
+		payload: *NewReceiptPayload(recipientName, orderNumber, currency, paymentMethod),
+	}
+}
+
+// AddElement adds a line item for a purchased item to the receipt.
+func (b *ReceiptBuilder) AddElement(element *ReceiptElement) *ReceiptBuilder {
+	b.payload.Elements = append(b.payload.Elements, element)
+
+	return b
+}
+
+// SetAddress sets the shipping address of the receipt.
+func (b *ReceiptBuilder) SetAddress(address *Address) *ReceiptBuilder {
+	b.payload.Address = address
+
+	return b
+}
+
+// SetSummary sets the totals of the receipt.
+func (b *ReceiptBuilder) SetSummary(summary *ReceiptSummary) *ReceiptBuilder {
+	b.payload.Summary = summary
+
+	return b
+}
+
+// AddAdjustment adds a discount or other adjustment to the receipt.
+func (b *ReceiptBuilder) AddAdjustment(adjustment *ReceiptAdjustment) *ReceiptBuilder {
+	b.payload.Adjustments = append(b.payload.Adjustments, adjustment)
+
+	return b
+}
+
+// SetOrderURL sets the URL of the order, which Facebook displays as a link on the receipt.
+func (b *ReceiptBuilder) SetOrderURL(url string) *ReceiptBuilder {
+	b.payload.OrderURL = url
+
+	return b
+}
+
+// SetTimestamp sets the order date of the receipt.
+func (b *ReceiptBuilder) SetTimestamp(t time.Time) *ReceiptBuilder {
+	b.payload.SetOrderDate(t)
+
+	return b
+}
+
+/*
+Build returns the receipt after checking that it is valid and has at least one element,
+that every element has a positive quantity and that it has a summary with a total cost that
+is not negative. The receipt is returned by value, so later changes to the builder do not
+affect it. Use a pointer to it as the Payload of a template Attachment to send it.
+*/
+func (b *ReceiptBuilder) Build() (ReceiptPayload, error) {
+	payload := b.payload
+	payload.Elements = append([]*ReceiptElement(nil), b.payload.Elements...)
+	payload.Adjustments = append([]*ReceiptAdjustment(nil), b.payload.Adjustments...)
+
+	if err := payload.Validate(); err != nil {
+		return ReceiptPayload{}, err
+	}
+
+	if len(payload.Elements) == 0 {
+		return ReceiptPayload{}, fmt.Errorf("ReceiptPayload.Elements is required")
+	}
+
+	for i, element := range payload.Elements {
+		if element.Quantity <= 0 {
+			return ReceiptPayload{}, fmt.Errorf("ReceiptPayload.Elements[%v]: ReceiptElement.Quantity must be positive", i)
+		}
+	}
+
+	if payload.Summary == nil {
+		return ReceiptPayload{}, fmt.Errorf("ReceiptPayload.Summary is required")
+	}
+
+	totalCost, err := payload.Summary.TotalCost.Float64()
+	if err != nil {
+		return ReceiptPayload{}, fmt.Errorf("ReceiptSummary.TotalCost is not a number: %v", err)
+	}
+
+	if totalCost < 0 {
+		return ReceiptPayload{}, fmt.Errorf("ReceiptSummary.TotalCost must not be negative")
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,122 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"time"
+)
+
+var _ = Describe("ReceiptBuilder", func() {
+	var (
+		builder    *ReceiptBuilder
+		whiteShirt *ReceiptElement
+		summary    *ReceiptSummary
+	)
+
+	BeforeEach(func() {
+		whiteShirt = &ReceiptElement{
+			Title:    "Classic White T-Shirt",
+			Subtitle: "100% Soft and Luxurious Cotton",
+			Quantity: 2,
+			Price:    "50",
+			Currency: "USD",
+			ImageURL: "http://petersapparel.parseapp.com/img/whiteshirt.png",
+		}
+
+		summary = &ReceiptSummary{
+			Subtotal:     "75.00",
+			ShippingCost: "4.95",
+			TotalTax:     "6.19",
+			TotalCost:    "56.14",
+		}
+
+		builder = NewReceiptBuilder("Stephane Crozatier", "12345678902", "USD", "Visa 2345")
+	})
+
+	It("should build the same receipt as the receipt template helper", func() {
+		payload, err := builder.
+			SetOrderURL("http://petersapparel.parseapp.com/order?order_id=123456").
+			SetTimestamp(time.Unix(1428444852, 0)).
+			AddElement(whiteShirt).
+			AddElement(&ReceiptElement{
+				Title:    "Classic Gray T-Shirt",
+				Subtitle: "100% Soft and Luxurious Cotton",
+				Quantity: 1,
+				Price:    "25",
+				Currency: "USD",
+				ImageURL: "http://petersapparel.parseapp.com/img/grayshirt.png",
+			}).
+			SetAddress(&Address{
+				Street1:    "1 Hacker Way",
+				City:       "Menlo Park",
+				PostalCode: "94025",
+				State:      "CA",
+				Country:    "US",
+			}).
+			SetSummary(summary).
+			AddAdjustment(&ReceiptAdjustment{Name: "New Customer Discount", Amount: "20"}).
+			AddAdjustment(&ReceiptAdjustment{Name: "$10 Off Coupon", Amount: "10"}).
+			Build()
+
+		Expect(err).To(BeNil())
+
+		sendRequest := &SendRequest{
+			Message: Message{
+				Attachment: &Attachment{
+					Type:    "template",
+					Payload: &payload,
+				},
+			},
+		}
+
+		expectCorrectMarshaling(sendRequest.To("USER_ID"), "message-with-receipt-attachment.json")
+	})
+
+	It("should not change a built receipt when the builder is changed", func() {
+		payload, _ := builder.AddElement(whiteShirt).SetSummary(summary).Build()
+
+		builder.AddElement(whiteShirt)
+
+		Expect(payload.Elements).To(HaveLen(1))
+	})
+
+	It("should require at least one element", func() {
+		_, err := builder.SetSummary(summary).Build()
+
+		Expect(err).To(MatchError("ReceiptPayload.Elements is required"))
+	})
+
+	It("should require elements to have a positive quantity", func() {
+		whiteShirt.Quantity = 0
+
+		_, err := builder.AddElement(whiteShirt).SetSummary(summary).Build()
+
+		Expect(err).To(MatchError("ReceiptPayload.Elements[0]: ReceiptElement.Quantity must be positive"))
+	})
+
+	It("should require a summary", func() {
+		_, err := builder.AddElement(whiteShirt).Build()
+
+		Expect(err).To(MatchError("ReceiptPayload.Summary is required"))
+	})
+
+	It("should reject a negative total cost", func() {
+		summary.TotalCost = "-1.00"
+
+		_, err := builder.AddElement(whiteShirt).SetSummary(summary).Build()
+
+		Expect(err).To(MatchError("ReceiptSummary.TotalCost must not be negative"))
+	})
+
+	It("should require an order number", func() {
+		_, err := NewReceiptBuilder("Stephane Crozatier", "", "USD", "Visa 2345").
+			AddElement(whiteShirt).
+			SetSummary(summary).
+			Build()
+
+		Expect(err).To(MatchError("ReceiptPayload.OrderNumber is required"))
+	})
+})
@@ -0,0 +1,599 @@
+package fbmessenger
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*------------------------------------------------------
+Send API
+------------------------------------------------------*/
+
+const (
+	// MaxPayloadSize is the maximum number of characters in the payload of a button or quick reply.
+	MaxPayloadSize = 1000
+
+	// MaxOptInRefSize is the maximum number of characters in the ref of an opt in.
+	MaxOptInRefSize = 50
+
+	// MaxTextLength is the maximum number of characters in the text of a message.
+	MaxTextLength = 2000
+)
+
+// validator is implemented by payloads that can check themselves against Facebook's limits.
+type validator interface {
+	Validate() error
+}
+
+/*
+Validate checks the request against the limits Facebook enforces on messages, so that
+problems are caught before a request is sent. The payload of an attachment is validated
+when it has a Validate method.
+*/
+func (sr *SendRequest) Validate() error {
+	if sr.phoneNumberErr != nil {
+		return fmt.Errorf("Recipient.PhoneNumber: %v", sr.phoneNumberErr)
+	}
+
+	if sr.Recipient.Id != "" && sr.Recipient.PhoneNumber != "" {
+		return fmt.Errorf("Recipient.Id and Recipient.PhoneNumber cannot both be set")
+	}
+
+	if err := sr.Recipient.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	if sr.Recipient.Id != "" && sr.Recipient.Name != nil {
+		return fmt.Errorf("Recipient.Name can only be set with Recipient.PhoneNumber")
+	}
+
+	if err := validateLength("Message.Text", sr.Message.Text, MaxTextLength); err != nil {
+		return err
+	}
+
+	if err := validateCount("Message.QuickReplies", len(sr.Message.QuickReplies), 11); err != nil {
+		return err
+	}
+
+	if len(sr.Message.QuickReplies) > 0 && sr.Message.Attachment != nil && sr.Message.Attachment.Type == "template" {
+		return fmt.Errorf("Message.QuickReplies cannot be set on a template message")
+	}
+
+	for i, reply := range sr.Message.QuickReplies {
+		if err := reply.Validate(); err != nil {
+			return fmt.Errorf("Message.QuickReplies[%v]: %v", i, err)
+		}
+	}
+
+	if sr.Message.Attachment != nil {
+		if v, ok := sr.Message.Attachment.Payload.(validator); ok {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the sender action is one of the SenderAction constants.
+func (sar *SenderActionRequest) Validate() error {
+	if err := sar.Recipient.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	switch sar.SenderAction {
+	case SenderActionTypingOn, SenderActionTypingOff, SenderActionMarkSeen:
+		return nil
+	case "":
+		return fmt.Errorf("SenderActionRequest.SenderAction is required")
+	default:
+		return fmt.Errorf("SenderActionRequest.SenderAction %q is not a sender action", sar.SenderAction)
+	}
+}
+
+/*
+Validate checks the quick reply against the limits Facebook enforces on quick replies.
+Text quick replies must have a title of at most 20 characters and a payload of at most
+MaxPayloadSize characters, and the image of a text quick reply, when set, must be an HTTPS URL.
+*/
+func (qr *QuickReply) Validate() error {
+	if qr.ContentType != "text" {
+		return nil
+	}
+
+	if qr.Title == "" {
+		return fmt.Errorf("QuickReply.Title is required")
+	}
+
+	if err := validateLength("QuickReply.Title", qr.Title, 20); err != nil {
+		return err
+	}
+
+	if err := validateLength("QuickReply.Payload", qr.Payload, MaxPayloadSize); err != nil {
+		return err
+	}
+
+	if qr.ImageURL != "" && !strings.HasPrefix(qr.ImageURL, "https://") {
+		return fmt.Errorf("QuickReply.ImageURL must be an https URL")
+	}
+
+	return nil
+}
+
+// Validate checks that the template type is one of the TemplateType constants.
+func (t TemplateType) Validate() error {
+	switch t {
+	case TemplateTypeButton, TemplateTypeGeneric, TemplateTypeList, TemplateTypeReceipt,
+		TemplateTypeMedia, TemplateTypeOpenGraph, TemplateTypeAirlineItinerary,
+		TemplateTypeAirlineBoardingPass, TemplateTypeAirlineCheckin,
+		TemplateTypeAirlineFlightUpdate, TemplateTypeOneTimeNotif, TemplateTypeCustomerFeedback:
+		return nil
+	}
+
+	return fmt.Errorf("template type %q is not recognized", string(t))
+}
+
+// Validate checks the payload against the limits Facebook enforces on button template
+// messages. Every button is validated, and the errors of all invalid buttons are reported.
+func (p ButtonPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	if p.Text == "" {
+		return fmt.Errorf("ButtonPayload.Text is required")
+	}
+
+	if err := validateLength("ButtonPayload.Text", p.Text, 640); err != nil {
+		return err
+	}
+
+	if len(p.Buttons) == 0 {
+		return fmt.Errorf("ButtonPayload.Buttons is required")
+	}
+
+	if err := validateCount("ButtonPayload.Buttons", len(p.Buttons), 3); err != nil {
+		return err
+	}
+
+	var violations []string
+	for i, button := range p.Buttons {
+		if button == nil {
+			violations = append(violations, fmt.Sprintf("ButtonPayload.Buttons[%v] is required", i))
+		} else if err := button.Validate(); err != nil {
+			violations = append(violations, fmt.Sprintf("ButtonPayload.Buttons[%v]: %v", i, err))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%v", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// Validate checks each element of the payload against the limits Facebook enforces on
+// generic template messages.
+func (p GenericPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	switch p.ImageAspectRatio {
+	case "", ImageAspectRatioHorizontal, ImageAspectRatioSquare:
+	default:
+		return fmt.Errorf("GenericPayload.ImageAspectRatio %q is not recognized", p.ImageAspectRatio)
+	}
+
+	for i, element := range p.Elements {
+		if element == nil {
+			return fmt.Errorf("GenericPayload.Elements[%v] is required", i)
+		}
+
+		if err := element.Validate(); err != nil {
+			return fmt.Errorf("GenericPayload.Elements[%v]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the payload has a recognized template type and at most one global
+// button.
+func (p ListPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateCount("ListPayload.Buttons", len(p.Buttons), 1); err != nil {
+		return err
+	}
+
+	for i, button := range p.Buttons {
+		if button == nil {
+			return fmt.Errorf("ListPayload.Buttons[%v] is required", i)
+		}
+
+		if err := button.Validate(); err != nil {
+			return fmt.Errorf("ListPayload.Buttons[%v]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the payload has a recognized template type.
+func (p MediaPayload) Validate() error {
+	return p.TemplateType.Validate()
+}
+
+// Validate checks the payload against the limits Facebook enforces on one-time notification
+// requests: a title of at most 65 characters and a payload of at most MaxPayloadSize characters.
+func (p OneTimeNotificationRequestPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	if p.Title == "" {
+		return fmt.Errorf("OneTimeNotificationRequestPayload.Title is required")
+	}
+
+	if err := validateLength("OneTimeNotificationRequestPayload.Title", p.Title, 65); err != nil {
+		return err
+	}
+
+	return validateLength("OneTimeNotificationRequestPayload.Payload", p.Payload, MaxPayloadSize)
+}
+
+/*
+Validate checks that the payload has a recognized template type and, when ExpiresInDays is
+set, that it is between 1 and 7 days, the range Facebook accepts. When it is not set,
+Facebook's default of 1 day is used.
+*/
+func (p CustomerFeedbackPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	if p.ExpiresInDays < 0 || p.ExpiresInDays > 7 {
+		return fmt.Errorf("CustomerFeedbackPayload.ExpiresInDays is %v, expected from 1 to 7", p.ExpiresInDays)
+	}
+
+	return nil
+}
+
+/*
+Validate checks that the payload has a recognized template type and an order number.
+Facebook also requires order numbers to be unique, as it deduplicates receipts by order
+number, but that can't be checked here.
+*/
+func (p *ReceiptPayload) Validate() error {
+	if err := p.TemplateType.Validate(); err != nil {
+		return err
+	}
+
+	if p.OrderNumber == "" {
+		return fmt.Errorf("ReceiptPayload.OrderNumber is required")
+	}
+
+	return nil
+}
+
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+/*
+Validate checks that the button has a known type and the fields that type requires. Share,
+login and logout buttons have no title, all other buttons must have a title of at most 20
+characters. URL buttons must have an HTTP or HTTPS URL, login buttons an absolute URL,
+postback buttons a Payload and call buttons a phone number in E.164 format as their Payload.
+*/
+func (b *Button) Validate() error {
+	switch b.Type {
+	case "web_url", "postback", "phone_number", "game_play":
+		if b.Title == "" {
+			return fmt.Errorf("Button.Title is required")
+		}
+
+		if err := validateLength("Button.Title", b.Title, 20); err != nil {
+			return err
+		}
+	case "element_share", "account_link", "account_unlink":
+	default:
+		return fmt.Errorf("Button.Type %q is not recognized", b.Type)
+	}
+
+	switch b.Type {
+	case "web_url":
+		if !strings.HasPrefix(b.URL, "https://") && !strings.HasPrefix(b.URL, "http://") {
+			return fmt.Errorf("Button.URL must be an http or https URL")
+		}
+	case "account_link":
+		if u, err := url.Parse(b.URL); err != nil || !u.IsAbs() {
+			return fmt.Errorf("Button.URL must be an absolute URL")
+		}
+	case "postback":
+		if b.Payload == "" {
+			return fmt.Errorf("Button.Payload is required")
+		}
+
+		return validateLength("Button.Payload", b.Payload, MaxPayloadSize)
+	case "game_play":
+		return validateLength("Button.Payload", b.Payload, MaxPayloadSize)
+	case "phone_number":
+		if !phoneNumberPattern.MatchString(b.Payload) {
+			return fmt.Errorf("Button.Payload must be a phone number in E.164 format")
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the element against the limits Facebook enforces on elements of
+// generic template messages.
+func (e *GenericElement) Validate() error {
+	if e.Title == "" {
+		return fmt.Errorf("GenericElement.Title is required")
+	}
+
+	if err := validateLength("GenericElement.Title", e.Title, 80); err != nil {
+		return err
+	}
+
+	if err := validateLength("GenericElement.Subtitle", e.Subtitle, 80); err != nil {
+		return err
+	}
+
+	return validateCount("GenericElement.Buttons", len(e.Buttons), 3)
+}
+
+func validateLength(field, value string, limit int) error {
+	if length := utf8.RuneCountInString(value); length > limit {
+		return fmt.Errorf("%v is %v characters, exceeding the limit of %v", field, length, limit)
+	}
+
+	return nil
+}
+
+// validateIdentifiers checks that no more than one of the ways of identifying the recipient
+// is set. A recipient with none is accepted, so that messages can be validated before they
+// are addressed.
+func (r Recipient) validateIdentifiers() error {
+	identifiers := 0
+	for _, identifier := range []string{r.Id, r.PhoneNumber, r.UserRef, r.OneTimeNotifToken} {
+		if identifier != "" {
+			identifiers++
+		}
+	}
+
+	if identifiers > 1 {
+		return fmt.Errorf("only one of Recipient.Id, Recipient.PhoneNumber, Recipient.UserRef and Recipient.OneTimeNotifToken can be set")
+	}
+
+	return nil
+}
+
+func validateCount(field string, count, limit int) error {
+	if count > limit {
+		return fmt.Errorf("%v has %v items, exceeding the limit of %v", field, count, limit)
+	}
+
+	return nil
+}
+
+/*------------------------------------------------------
+Broadcasts
+------------------------------------------------------*/
+
+// MinBroadcastScheduleDelay is how far in the future Facebook requires a broadcast to be scheduled.
+const MinBroadcastScheduleDelay = 5 * time.Minute
+
+// Validate checks the request against the limits Facebook enforces on broadcasts.
+func (r *BroadcastRequest) Validate() error {
+	if r.MessageCreativeId == 0 {
+		return fmt.Errorf("BroadcastRequest.MessageCreativeId is required")
+	}
+
+	if r.ScheduledTime != nil && r.ScheduledTime.Before(time.Now().Add(MinBroadcastScheduleDelay)) {
+		return fmt.Errorf("BroadcastRequest.ScheduledTime must be at least %v in the future", MinBroadcastScheduleDelay)
+	}
+
+	return nil
+}
+
+// Validate checks that the variant is named and has messages to broadcast.
+func (ab *ABTestBroadcast) Validate() error {
+	if ab.Variant == "" {
+		return fmt.Errorf("ABTestBroadcast.Variant is required")
+	}
+
+	if len(ab.Messages) == 0 {
+		return fmt.Errorf("ABTestBroadcast.Messages is required")
+	}
+
+	return nil
+}
+
+func validateABTest(variants []*ABTestBroadcast) error {
+	if len(variants) < 2 {
+		return fmt.Errorf("an A/B test has %v variants, at least 2 are required", len(variants))
+	}
+
+	names := map[string]bool{}
+	for i, variant := range variants {
+		if variant == nil {
+			return fmt.Errorf("variants[%v] is required", i)
+		}
+
+		if err := variant.Validate(); err != nil {
+			return fmt.Errorf("variants[%v]: %v", i, err)
+		}
+
+		if names[variant.Variant] {
+			return fmt.Errorf("variants[%v]: ABTestBroadcast.Variant %q is not unique", i, variant.Variant)
+		}
+		names[variant.Variant] = true
+	}
+
+	return nil
+}
+
+/*------------------------------------------------------
+Messenger Profile
+------------------------------------------------------*/
+
+// Validate checks that the greeting has text within the limit and a supported locale.
+func (g *Greeting) Validate() error {
+	if g.Locale == "" {
+		return fmt.Errorf("Greeting.Locale is required")
+	}
+
+	supported := false
+	for _, locale := range supportedLocales {
+		if g.Locale == locale {
+			supported = true
+			break
+		}
+	}
+
+	if !supported {
+		return fmt.Errorf("Greeting.Locale %q is not supported", g.Locale)
+	}
+
+	if g.Text == "" {
+		return fmt.Errorf("Greeting.Text is required")
+	}
+
+	return validateLength("Greeting.Text", g.Text, 160)
+}
+
+// Validate checks the ice breaker against the limits Facebook enforces on ice breakers.
+func (ib *IceBreaker) Validate() error {
+	if ib.Question == "" {
+		return fmt.Errorf("IceBreaker.Question is required")
+	}
+
+	if err := validateLength("IceBreaker.Question", ib.Question, 80); err != nil {
+		return err
+	}
+
+	if ib.Payload == "" {
+		return fmt.Errorf("IceBreaker.Payload is required")
+	}
+
+	return nil
+}
+
+func validateIceBreakers(iceBreakers []*IceBreaker) error {
+	if err := validateCount("ice breakers", len(iceBreakers), 4); err != nil {
+		return err
+	}
+
+	for i, iceBreaker := range iceBreakers {
+		if err := iceBreaker.Validate(); err != nil {
+			return fmt.Errorf("ice breakers[%v]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+Validate checks that the home URL is an absolute HTTPS URL on one of the whitelisted domains
+of the page, which Facebook requires. A home URL is never valid without whitelisted domains.
+*/
+func (h *HomeURL) Validate(whitelistedDomains ...string) error {
+	homeURL, err := url.Parse(h.URL)
+	if err != nil || homeURL.Host == "" {
+		return fmt.Errorf("HomeURL.URL %q is not an absolute URL", h.URL)
+	}
+
+	if homeURL.Scheme != "https" {
+		return fmt.Errorf("HomeURL.URL %q must use https", h.URL)
+	}
+
+	for _, domain := range whitelistedDomains {
+		domainURL, err := url.Parse(domain)
+		if err == nil && strings.EqualFold(domainURL.Host, homeURL.Host) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("HomeURL.URL %q is not on a whitelisted domain", h.URL)
+}
+
+/*------------------------------------------------------
+Webhook
+------------------------------------------------------*/
+
+// Validate checks that the ref is no longer than MaxOptInRefSize characters, so that a ref
+// can be checked before it is used in a Send-to-Messenger plugin or m.me link.
+func (o *OptIn) Validate() error {
+	return validateLength("OptIn.Ref", o.Ref, MaxOptInRefSize)
+}
+
+// CallbackValidationError is returned when a callback does not satisfy the invariants
+// Facebook guarantees for callbacks. It lists every violation found, not just the first.
+type CallbackValidationError struct {
+	Violations []string
+}
+
+func (e *CallbackValidationError) Error() string {
+	return "invalid callback: " + strings.Join(e.Violations, "; ")
+}
+
+/*
+Validate checks that the callback has the shape Facebook guarantees for page callbacks:
+Object is "page" or "instagram", there is at least one entry, each entry has a page id and each messaging
+entry has a sender id and recipient id. Checkbox plugin opt-ins have no sender, only a user ref,
+so they need only a recipient id. A *CallbackValidationError is returned when any of these
+checks fail.
+*/
+func (cb *Callback) Validate() error {
+	var violations []string
+
+	if platform := cb.Platform(); platform != PlatformMessenger && platform != PlatformInstagram {
+		violations = append(violations, fmt.Sprintf("object is %q, expected \"page\" or \"instagram\"", cb.Object))
+	}
+
+	if len(cb.Entries) == 0 {
+		violations = append(violations, "callback has no entries")
+	}
+
+	for i, entry := range cb.Entries {
+		if entry == nil {
+			violations = append(violations, fmt.Sprintf("entry[%v] is null", i))
+			continue
+		}
+
+		if entry.PageId == "" {
+			violations = append(violations, fmt.Sprintf("entry[%v] has no page id", i))
+		}
+
+		for j, messagingEntry := range entry.Messaging {
+			if messagingEntry == nil {
+				violations = append(violations, fmt.Sprintf("entry[%v].messaging[%v] is null", i, j))
+				continue
+			}
+
+			checkboxOptIn := messagingEntry.OptIn != nil && messagingEntry.OptIn.UserRef != ""
+			if messagingEntry.Sender.Id == "" && !checkboxOptIn {
+				violations = append(violations, fmt.Sprintf("entry[%v].messaging[%v] has no sender id", i, j))
+			}
+
+			if messagingEntry.Recipient.Id == "" {
+				violations = append(violations, fmt.Sprintf("entry[%v].messaging[%v] has no recipient id", i, j))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &CallbackValidationError{Violations: violations}
+	}
+
+	return nil
+}
@@ -0,0 +1,541 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Validation", func() {
+	Describe("Recipient", func() {
+		It("should accept a phone number recipient with a name", func() {
+			sendRequest := TextMessage("Hello, world!").ToPhoneNumberWithName("+1(212)555-2368", "John", "Doe")
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject a recipient with both an id and a phone number", func() {
+			sendRequest := TextMessage("Hello, world!").To("USER_ID")
+			sendRequest.Recipient.PhoneNumber = "+1(212)555-2368"
+
+			Expect(sendRequest.Validate()).To(MatchError("Recipient.Id and Recipient.PhoneNumber cannot both be set"))
+		})
+
+		It("should reject a recipient set directly with both a user ref and a phone number", func() {
+			sendRequest := TextMessage("Hello, world!").ToRecipient(Recipient{UserRef: "USER_REF", PhoneNumber: "+12125552368"})
+
+			Expect(sendRequest.Validate()).To(MatchError("only one of Recipient.Id, Recipient.PhoneNumber, Recipient.UserRef and Recipient.OneTimeNotifToken can be set"))
+		})
+
+		It("should accept a recipient set directly with a user ref", func() {
+			sendRequest := TextMessage("Hello, world!").ToRecipient(Recipient{UserRef: "USER_REF"})
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject a name for a recipient with an id", func() {
+			sendRequest := TextMessage("Hello, world!").To("USER_ID")
+			sendRequest.Recipient.Name = &RecipientName{FirstName: "John", LastName: "Doe"}
+
+			Expect(sendRequest.Validate()).To(MatchError("Recipient.Name can only be set with Recipient.PhoneNumber"))
+		})
+	})
+
+	Describe("Sender Action", func() {
+		It("should reject an action that is not a sender action", func() {
+			request := &SenderActionRequest{Recipient: Recipient{Id: "USER_ID"}, SenderAction: "typing"}
+
+			Expect(request.Validate()).To(MatchError(`SenderActionRequest.SenderAction "typing" is not a sender action`))
+		})
+	})
+
+	Describe("Text", func() {
+		It("should accept text of 2000 characters", func() {
+			Expect(TextMessage(strings.Repeat("a", 2000)).Validate()).To(BeNil())
+
+			sendRequest, err := NewTextMessage(strings.Repeat("a", 2000))
+			Expect(err).To(BeNil())
+			Expect(sendRequest.Message.Text).To(HaveLen(2000))
+		})
+
+		It("should reject text over 2000 characters", func() {
+			Expect(TextMessage(strings.Repeat("a", 2001)).Validate()).To(MatchError("Message.Text is 2001 characters, exceeding the limit of 2000"))
+
+			_, err := NewTextMessage(strings.Repeat("a", 2001))
+			Expect(err).To(MatchError("Message.Text is 2001 characters, exceeding the limit of 2000"))
+		})
+
+		It("should return an empty message body for text over 2000 characters", func() {
+			message, err := NewTextMessageBody(strings.Repeat("a", 2001))
+
+			Expect(err).To(MatchError("Message.Text is 2001 characters, exceeding the limit of 2000"))
+			Expect(message).To(Equal(Message{}))
+		})
+	})
+
+	Describe("Quick Replies", func() {
+		It("should accept up to 11 quick replies", func() {
+			sendRequest := TextMessage("Pick a color:")
+			for i := 0; i < 11; i++ {
+				sendRequest.WithQuickReplies(TextReply("Red", "PICKED_RED"))
+			}
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject more than 11 quick replies", func() {
+			sendRequest := TextMessage("Pick a color:")
+			for i := 0; i < 12; i++ {
+				sendRequest.WithQuickReplies(TextReply("Red", "PICKED_RED"))
+			}
+
+			Expect(sendRequest.Validate()).To(MatchError("Message.QuickReplies has 12 items, exceeding the limit of 11"))
+		})
+
+		It("should accept a text quick reply with an image", func() {
+			sendRequest := TextMessage("Pick a color:").
+				WithQuickReplies(TextReplyWithImage("Red", "PICKED_RED", "https://petersfancyapparel.com/img/red.png"))
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject a quick reply image that is not an https url", func() {
+			sendRequest := TextMessage("Pick a color:").
+				WithQuickReplies(TextReplyWithImage("Red", "PICKED_RED", "http://petersfancyapparel.com/img/red.png"))
+
+			Expect(sendRequest.Validate()).To(MatchError("Message.QuickReplies[0]: QuickReply.ImageURL must be an https URL"))
+		})
+
+		It("should reject a text quick reply without a title", func() {
+			reply := TextReplyWithImage("", "PICKED_RED", "https://petersfancyapparel.com/img/red.png")
+
+			Expect(reply.Validate()).To(MatchError("QuickReply.Title is required"))
+		})
+
+		It("should reject a quick reply with a title over 20 characters", func() {
+			reply := TextReply(strings.Repeat("a", 21), "PAYLOAD")
+
+			Expect(reply.Validate()).To(MatchError("QuickReply.Title is 21 characters, exceeding the limit of 20"))
+		})
+
+		It("should reject a quick reply with a payload over 1000 characters", func() {
+			reply := TextReply("Red", strings.Repeat("a", MaxPayloadSize+1))
+
+			Expect(reply.Validate()).To(MatchError("QuickReply.Payload is 1001 characters, exceeding the limit of 1000"))
+		})
+
+		It("should accept a location quick reply", func() {
+			Expect(LocationReply().Validate()).To(BeNil())
+		})
+
+		It("should reject quick replies on a template message", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?", PostbackButton("A", "A")).
+				WithQuickReplies(TextReply("Red", "PICKED_RED"))
+
+			Expect(sendRequest.Validate()).To(MatchError("Message.QuickReplies cannot be set on a template message"))
+		})
+	})
+
+	Describe("Button Template", func() {
+		var buttons []*Button
+
+		BeforeEach(func() {
+			buttons = []*Button{
+				URLButton("Show Website", "https://petersapparel.parseapp.com"),
+				PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD"),
+			}
+		})
+
+		It("should accept a valid button template message", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?", buttons...).To("USER_ID")
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject a button template message without text", func() {
+			sendRequest := ButtonTemplateMessage("", buttons...)
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Text is required"))
+		})
+
+		It("should reject a button template message with text over 640 characters", func() {
+			sendRequest := ButtonTemplateMessage(strings.Repeat("a", 641), buttons...)
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Text is 641 characters, exceeding the limit of 640"))
+		})
+
+		It("should reject a button template message without buttons", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?")
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Buttons is required"))
+		})
+
+		It("should report every invalid button of a button template message", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?",
+				URLButton("Show Website", "petersapparel.parseapp.com"),
+				PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD"),
+				PostbackButton("", "USER_DEFINED_PAYLOAD"))
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Buttons[0]: Button.URL must be an http or https URL; ButtonPayload.Buttons[2]: Button.Title is required"))
+		})
+
+		It("should reject a nil button of a button template message", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?", PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD"), nil)
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Buttons[1] is required"))
+		})
+
+		It("should reject a button template message with more than 3 buttons", func() {
+			buttons = append(buttons, PostbackButton("B", "B"), PostbackButton("C", "C"))
+			sendRequest := ButtonTemplateMessage("What do you want to do next?", buttons...)
+
+			Expect(sendRequest.Validate()).To(MatchError("ButtonPayload.Buttons has 4 items, exceeding the limit of 3"))
+		})
+	})
+
+	Describe("Buttons", func() {
+		It("should accept buttons built by the button helpers", func() {
+			for _, button := range []*Button{
+				URLButton("View Item", "https://petersapparel.parseapp.com/view_item?item_id=100"),
+				PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD"),
+				CallButton("Call Representative", "+16505551234"),
+				ShareButton(),
+				LoginButton("https://www.example.com/authorize"),
+				LogoutButton(),
+				GamePlayButton("Play", "{\"level\":1}"),
+			} {
+				Expect(button.Validate()).To(BeNil(), button.Type)
+			}
+		})
+
+		It("should reject a button without a title", func() {
+			Expect(PostbackButton("", "USER_DEFINED_PAYLOAD").Validate()).To(MatchError("Button.Title is required"))
+		})
+
+		It("should reject a button with a title over 20 characters", func() {
+			Expect(PostbackButton(strings.Repeat("a", 21), "PAYLOAD").Validate()).To(MatchError("Button.Title is 21 characters, exceeding the limit of 20"))
+		})
+
+		It("should reject a button with an unknown type", func() {
+			button := &Button{Type: "weburl", Title: "View Item"}
+
+			Expect(button.Validate()).To(MatchError(`Button.Type "weburl" is not recognized`))
+		})
+
+		It("should reject a URL button without an http or https url", func() {
+			Expect(URLButton("View Item", "view_item?item_id=100").Validate()).To(MatchError("Button.URL must be an http or https URL"))
+		})
+
+		It("should reject a login button without an absolute url", func() {
+			Expect(LoginButton("authorize").Validate()).To(MatchError("Button.URL must be an absolute URL"))
+		})
+
+		It("should reject a postback button with a payload over 1000 characters", func() {
+			button := PostbackButton("Start Chatting", strings.Repeat("a", MaxPayloadSize+1))
+
+			Expect(button.Validate()).To(MatchError("Button.Payload is 1001 characters, exceeding the limit of 1000"))
+		})
+
+		It("should reject a postback button without a payload", func() {
+			Expect(PostbackButton("Start Chatting", "").Validate()).To(MatchError("Button.Payload is required"))
+		})
+
+		It("should reject a call button without an E.164 phone number", func() {
+			Expect(CallButton("Call Representative", "650-555-1234").Validate()).To(MatchError("Button.Payload must be a phone number in E.164 format"))
+		})
+	})
+
+	Describe("Generic Template", func() {
+		var element *GenericElement
+
+		BeforeEach(func() {
+			element = &GenericElement{
+				Title:    "Welcome to Peter's Hats",
+				ImageURL: "http://petersapparel.parseapp.com/img/item100-thumb.png",
+				Subtitle: "We've got the right hat for everyone.",
+				Buttons:  []*Button{PostbackButton("Start Chatting", "USER_DEFINED_PAYLOAD")},
+			}
+		})
+
+		It("should accept a valid generic template message", func() {
+			Expect(GenericTemplateMessage(element).Validate()).To(BeNil())
+		})
+
+		It("should reject an element with a title over 80 characters", func() {
+			element.Title = strings.Repeat("a", 81)
+
+			Expect(GenericTemplateMessage(element).Validate()).To(MatchError("GenericPayload.Elements[0]: GenericElement.Title is 81 characters, exceeding the limit of 80"))
+		})
+
+		It("should reject a nil element", func() {
+			Expect(GenericTemplateMessage(element, nil).Validate()).To(MatchError("GenericPayload.Elements[1] is required"))
+		})
+
+		It("should accept a generic template message with square images", func() {
+			request := GenericTemplateMessageSquare(element)
+
+			Expect(request.Message.Attachment.Payload.(GenericPayload).ImageAspectRatio).To(Equal(ImageAspectRatioSquare))
+			Expect(request.Validate()).To(BeNil())
+		})
+
+		It("should reject an unrecognized image aspect ratio", func() {
+			payload := GenericPayload{TemplateType: TemplateTypeGeneric, Elements: []*GenericElement{element}}
+			payload.WithImageAspectRatio("circle")
+
+			Expect(payload.Validate()).To(MatchError(`GenericPayload.ImageAspectRatio "circle" is not recognized`))
+		})
+
+		It("should reject an element with a subtitle over 80 characters", func() {
+			element.Subtitle = strings.Repeat("a", 81)
+
+			Expect(element.Validate()).To(MatchError("GenericElement.Subtitle is 81 characters, exceeding the limit of 80"))
+		})
+
+		It("should reject an element with more than 3 buttons", func() {
+			element.Buttons = append(element.Buttons, element.Buttons[0], element.Buttons[0], element.Buttons[0])
+
+			Expect(element.Validate()).To(MatchError("GenericElement.Buttons has 4 items, exceeding the limit of 3"))
+		})
+	})
+
+	Describe("Template Type", func() {
+		It("should accept the template types set by the template helpers", func() {
+			for _, sendRequest := range []*SendRequest{
+				ButtonTemplateMessage("What do you want to do next?", PostbackButton("A", "A")),
+				ListTemplateMessage("", &ListElement{Title: "Classic T-Shirt Collection"}),
+				MediaTemplateMessage(&MediaElement{MediaType: "image", AttachmentId: "1854626884821032"}),
+				ReceiptTemplateMessage(&ReceiptHeader{OrderNumber: "12345678902"}, &ReceiptSummary{}),
+			} {
+				Expect(sendRequest.Validate()).To(BeNil())
+			}
+		})
+
+		It("should reject a template type that is not recognized", func() {
+			sendRequest := ButtonTemplateMessage("What do you want to do next?", PostbackButton("A", "A"))
+			payload := sendRequest.Message.Attachment.Payload.(ButtonPayload)
+			payload.TemplateType = "buton"
+			sendRequest.Message.Attachment.Payload = payload
+
+			Expect(sendRequest.Validate()).To(MatchError(`template type "buton" is not recognized`))
+		})
+	})
+
+	Describe("List Template", func() {
+		var element *ListElement
+
+		BeforeEach(func() {
+			element = &ListElement{Title: "Classic T-Shirt Collection"}
+		})
+
+		It("should accept a list template message with a global button", func() {
+			request := ListTemplateMessageWithAction(ListStyleCompact, PostbackButton("View More", "VIEW_MORE"), element)
+
+			Expect(request.Message.Attachment.Payload.(ListPayload).Buttons).To(HaveLen(1))
+			Expect(request.Validate()).To(BeNil())
+		})
+
+		It("should reject a list template message with a nil global button", func() {
+			Expect(ListTemplateMessageWithAction(ListStyleCompact, nil, element).Validate()).To(MatchError("ListPayload.Buttons[0] is required"))
+		})
+
+		It("should reject a list template message with more than 1 global button", func() {
+			request := ListTemplateMessageWithAction(ListStyleCompact, PostbackButton("View More", "VIEW_MORE"), element)
+			payload := request.Message.Attachment.Payload.(ListPayload)
+			payload.Buttons = append(payload.Buttons, PostbackButton("View Less", "VIEW_LESS"))
+			request.Message.Attachment.Payload = payload
+
+			Expect(request.Validate()).To(MatchError("ListPayload.Buttons has 2 items, exceeding the limit of 1"))
+		})
+	})
+
+	Describe("One-Time Notification Request", func() {
+		It("should accept a valid request", func() {
+			sendRequest := OneTimeNotificationRequestMessage("Notify me when the hat is back in stock", "HAT_RESTOCK")
+
+			Expect(sendRequest.Validate()).To(BeNil())
+		})
+
+		It("should reject a title over 65 characters", func() {
+			sendRequest := OneTimeNotificationRequestMessage(strings.Repeat("a", 66), "HAT_RESTOCK")
+
+			Expect(sendRequest.Validate()).To(MatchError("OneTimeNotificationRequestPayload.Title is 66 characters, exceeding the limit of 65"))
+		})
+
+		It("should reject a payload over 1000 characters", func() {
+			sendRequest := OneTimeNotificationRequestMessage("Notify me when the hat is back in stock", strings.Repeat("a", 1001))
+
+			Expect(sendRequest.Validate()).To(MatchError("OneTimeNotificationRequestPayload.Payload is 1001 characters, exceeding the limit of 1000"))
+		})
+	})
+
+	Describe("Customer Feedback Template", func() {
+		It("should accept an expiry of 1 to 7 days, or none", func() {
+			for _, days := range []int{0, 1, 7} {
+				Expect(CustomerFeedbackMessage(CustomerFeedbackPayload{ExpiresInDays: days}).Validate()).To(BeNil(), fmt.Sprint(days))
+			}
+		})
+
+		It("should reject an expiry outside 1 to 7 days", func() {
+			Expect(CustomerFeedbackMessage(CustomerFeedbackPayload{ExpiresInDays: 8}).Validate()).To(MatchError("CustomerFeedbackPayload.ExpiresInDays is 8, expected from 1 to 7"))
+			Expect(CustomerFeedbackMessage(CustomerFeedbackPayload{ExpiresInDays: -1}).Validate()).To(MatchError("CustomerFeedbackPayload.ExpiresInDays is -1, expected from 1 to 7"))
+		})
+	})
+
+	Describe("Receipt Template", func() {
+		It("should reject a receipt without an order number", func() {
+			payload := NewReceiptPayload("Stephane Crozatier", "", "USD", "Visa 2345")
+
+			Expect(payload.Validate()).To(MatchError("ReceiptPayload.OrderNumber is required"))
+		})
+	})
+
+	Describe("Broadcast", func() {
+		It("should accept a broadcast scheduled more than 5 minutes in the future", func() {
+			at := time.Now().Add(10 * time.Minute)
+			broadcast := &BroadcastRequest{MessageCreativeId: 938461089, ScheduledTime: &at}
+
+			Expect(broadcast.Validate()).To(BeNil())
+		})
+
+		It("should reject a broadcast scheduled less than 5 minutes in the future", func() {
+			at := time.Now().Add(time.Minute)
+			broadcast := &BroadcastRequest{MessageCreativeId: 938461089, ScheduledTime: &at}
+
+			Expect(broadcast.Validate()).To(MatchError("BroadcastRequest.ScheduledTime must be at least 5m0s in the future"))
+		})
+
+		It("should reject a broadcast with no message creative", func() {
+			broadcast := &BroadcastRequest{}
+
+			Expect(broadcast.Validate()).To(MatchError("BroadcastRequest.MessageCreativeId is required"))
+		})
+	})
+
+	Describe("Greeting", func() {
+		It("should accept a supported locale", func() {
+			greeting := (&Greeting{Text: "Hello {{user_first_name}}!"}).SetLocale(LocaleEnUS)
+
+			Expect(greeting.Validate()).To(BeNil())
+			Expect(SupportedLocales()).To(ContainElement(LocaleDefault))
+		})
+
+		It("should reject a locale that is not supported", func() {
+			greeting := &Greeting{Locale: "en-US", Text: "Hello {{user_first_name}}!"}
+
+			Expect(greeting.Validate()).To(MatchError(`Greeting.Locale "en-US" is not supported`))
+		})
+
+		It("should reject text over 160 characters", func() {
+			greeting := &Greeting{Locale: LocaleDefault, Text: strings.Repeat("a", 161)}
+
+			Expect(greeting.Validate()).To(MatchError("Greeting.Text is 161 characters, exceeding the limit of 160"))
+		})
+	})
+
+	Describe("Home URL", func() {
+		It("should accept an https url on a whitelisted domain", func() {
+			homeURL := &HomeURL{URL: "https://petersapparel.com/home"}
+
+			Expect(homeURL.Validate("https://petersapparel.com")).To(BeNil())
+		})
+
+		It("should reject a url that is not absolute", func() {
+			homeURL := &HomeURL{URL: "/home"}
+
+			Expect(homeURL.Validate()).To(MatchError(`HomeURL.URL "/home" is not an absolute URL`))
+		})
+
+		It("should reject a url when no domains are whitelisted", func() {
+			homeURL := &HomeURL{URL: "https://petersapparel.com/home"}
+
+			Expect(homeURL.Validate()).To(MatchError(`HomeURL.URL "https://petersapparel.com/home" is not on a whitelisted domain`))
+		})
+
+		It("should reject a url on a domain that is not whitelisted", func() {
+			homeURL := &HomeURL{URL: "https://example.com/home"}
+
+			Expect(homeURL.Validate("https://petersapparel.com")).To(MatchError(`HomeURL.URL "https://example.com/home" is not on a whitelisted domain`))
+		})
+	})
+
+	Describe("Opt In", func() {
+		It("should accept a ref of 50 characters", func() {
+			optIn := &OptIn{Ref: strings.Repeat("a", MaxOptInRefSize)}
+
+			Expect(optIn.Validate()).To(BeNil())
+		})
+
+		It("should reject a ref over 50 characters", func() {
+			optIn := &OptIn{Ref: strings.Repeat("a", MaxOptInRefSize+1)}
+
+			Expect(optIn.Validate()).To(MatchError("OptIn.Ref is 51 characters, exceeding the limit of 50"))
+		})
+	})
+
+	Describe("Callback", func() {
+		It("should accept the sample callbacks", func() {
+			for _, fileName := range []string{"text-message.json", "delivery.json", "postback.json", "authentication.json", "instagram-message.json"} {
+				var cb Callback
+				loadCallback(fileName, &cb)
+
+				Expect(cb.Validate()).To(BeNil(), fileName)
+			}
+		})
+
+		It("should reject a callback for an object other than a page", func() {
+			cb := createMessageCallback()
+			cb.Object = "user"
+
+			expectViolations(cb.Validate(), `object is "user", expected "page" or "instagram"`)
+		})
+
+		It("should reject a callback with no entries", func() {
+			cb := &Callback{Object: "page"}
+
+			expectViolations(cb.Validate(), "callback has no entries")
+		})
+
+		It("should reject an entry with no page id", func() {
+			cb := createMessageCallback()
+			cb.Entries[0].PageId = ""
+
+			expectViolations(cb.Validate(), "entry[0] has no page id")
+		})
+
+		It("should report every missing sender and recipient id", func() {
+			cb := createMessageCallback()
+			cb.Entries[0].Messaging[0].Sender.Id = ""
+			cb.Entries[0].Messaging[0].Recipient.Id = ""
+
+			expectViolations(cb.Validate(),
+				"entry[0].messaging[0] has no sender id",
+				"entry[0].messaging[0] has no recipient id")
+		})
+
+		It("should accept a checkbox plugin opt-in, which has no sender", func() {
+			cb, err := ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("checkbox-plugin-optin.json"))))
+
+			Expect(err).To(BeNil())
+			Expect(cb.Entries[0].Messaging[0].OptIn.UserRef).To(Equal("UNIQUE_REF_PARAM"))
+		})
+
+		It("should still require a sender for an opt-in without a user ref", func() {
+			cb := createMessageCallback()
+			cb.Entries[0].Messaging[0].Sender.Id = ""
+			cb.Entries[0].Messaging[0].OptIn = &OptIn{Ref: "PASS_THROUGH_PARAM"}
+
+			expectViolations(cb.Validate(), "entry[0].messaging[0] has no sender id")
+		})
+	})
+})
+
+func expectViolations(err error, violations ...string) {
+	Expect(err).To(BeAssignableToTypeOf(&CallbackValidationError{}))
+	Expect(err.(*CallbackValidationError).Violations).To(Equal(violations))
+}
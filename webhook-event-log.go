@@ -0,0 +1,143 @@
+package fbmessenger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+/*
+WebhookEventLog returns middleware that writes the raw body of each request received by your
+webhook endpoint to w before passing the request on to the next handler. Each payload is
+written on its own line (newline-delimited JSON) so the log can later be fed to ReplayEvents:
+JSON bodies are compacted and other bodies are written as JSON strings. Requests without a
+body, such as the verification challenge, are not logged. The body is buffered, so the next
+handler still receives it in full.
+
+At most DefaultMaxBodySize bytes are read, or the size set with WithMaxBodySize, which should
+be the same as the one given to the handler. Larger bodies are answered with a 413 and not
+logged. Errors writing to w do not stop the request being passed on; use WithWriteErrorHandler
+to be told about them.
+
+	http.Handle("/webhook", fbmessenger.WebhookEventLog(logFile)(webhookHandler))
+*/
+func WebhookEventLog(w io.Writer, options ...EventLogOption) func(http.Handler) http.Handler {
+	config := &eventLogConfig{}
+	for _, option := range options {
+		option.applyToEventLog(config)
+	}
+
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				body, err := readBody(r, config.parseOptions)
+				if err == ErrBodyTooLarge {
+					http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+					return
+				} else if err != nil {
+					http.Error(rw, "error reading request body", http.StatusBadRequest)
+					return
+				}
+
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+				mu.Lock()
+				err = writeEvent(w, body)
+				mu.Unlock()
+
+				if err != nil && config.onWriteError != nil {
+					config.onWriteError(r, err)
+				}
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+type eventLogConfig struct {
+	parseOptions []ParseOption
+	onWriteError func(r *http.Request, err error)
+}
+
+// EventLogOption is implemented by options that configure WebhookEventLog. Each ParseOption
+// is also an EventLogOption, which sets how much of each body is read.
+type EventLogOption interface {
+	applyToEventLog(c *eventLogConfig)
+}
+
+type eventLogOptionFunc func(c *eventLogConfig)
+
+func (o eventLogOptionFunc) applyToEventLog(c *eventLogConfig) {
+	o(c)
+}
+
+func (o ParseOption) applyToEventLog(c *eventLogConfig) {
+	c.parseOptions = append(c.parseOptions, o)
+}
+
+// WithWriteErrorHandler makes WebhookEventLog call f with each error writing a request to
+// the log.
+func WithWriteErrorHandler(f func(r *http.Request, err error)) EventLogOption {
+	return eventLogOptionFunc(func(c *eventLogConfig) {
+		c.onWriteError = f
+	})
+}
+
+func writeEvent(w io.Writer, body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var line bytes.Buffer
+	if err := json.Compact(&line, body); err != nil {
+		line.Reset()
+		raw, _ := json.Marshal(string(body))
+		line.Write(raw)
+	}
+
+	line.WriteByte('\n')
+	_, err := w.Write(line.Bytes())
+	return err
+}
+
+/*
+ReplayEvents reads callbacks logged by WebhookEventLog from r and passes each one to handler,
+in the order they were logged. Lines holding a body that was not JSON are skipped. Replaying
+stops at the first line that is not a valid callback or the first error returned by handler.
+
+	dispatcher := &fbmessenger.CallbackDispatcher{MessageHandler: MessageReceived}
+	err := fbmessenger.ReplayEvents(logFile, dispatcher.Dispatch)
+*/
+func ReplayEvents(r io.Reader, handler func(*Callback) error) error {
+	reader := bufio.NewReader(r)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] != '"' {
+			cb := &Callback{}
+			if jsonErr := json.Unmarshal(line, cb); jsonErr != nil {
+				return fmt.Errorf("error unmarshaling event on line %v: %v", lineNumber, jsonErr)
+			}
+
+			if handlerErr := handler(cb); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+var _ = Describe("WebhookEventLog", func() {
+	var (
+		log          *bytes.Buffer
+		receivedBody string
+		handler      http.Handler
+	)
+
+	BeforeEach(func() {
+		log = &bytes.Buffer{}
+		receivedBody = ""
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			receivedBody = string(body)
+		})
+
+		handler = WebhookEventLog(log)(next)
+	})
+
+	It("should write each payload on its own line without consuming the body", func() {
+		body := loadCallbackString("text-message.json")
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+
+		Expect(receivedBody).To(Equal(body))
+
+		lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(HavePrefix(`{"object":"page","entry":[`))
+	})
+
+	It("should not log requests without a json body", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/webhook?hub.challenge=123", nil))
+
+		Expect(log.Len()).To(Equal(0))
+	})
+
+	It("should log a body that is not json as a string", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader("not json")))
+
+		Expect(log.String()).To(Equal("\"not json\"\n"))
+		Expect(receivedBody).To(Equal("not json"))
+	})
+
+	It("should reject a body over the limit without logging it", func() {
+		handler = WebhookEventLog(log, WithMaxBodySize(100))(http.NotFoundHandler())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(log.Len()).To(Equal(0))
+	})
+
+	It("should report errors writing to the log and still pass the request on", func() {
+		writeErr := errors.New("disk full")
+		var reported []error
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			receivedBody = string(body)
+		})
+		handler = WebhookEventLog(failingWriter{writeErr}, WithWriteErrorHandler(func(r *http.Request, err error) {
+			reported = append(reported, err)
+		}))(next)
+
+		body := loadCallbackString("text-message.json")
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+
+		Expect(reported).To(Equal([]error{writeErr}))
+		Expect(receivedBody).To(Equal(body))
+	})
+
+	It("should replay logged events in order", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("postback.json"))))
+
+		var replayed []*Callback
+		err := ReplayEvents(log, func(cb *Callback) error {
+			replayed = append(replayed, cb)
+			return nil
+		})
+
+		Expect(err).To(BeNil())
+		Expect(replayed).To(HaveLen(2))
+		Expect(replayed[0].Entries[0].Messaging[0].Message.Text).To(Equal("hello, world!"))
+		Expect(replayed[1].Entries[0].Messaging[0].Postback.Payload).To(Equal("USER_DEFINED_PAYLOAD"))
+	})
+
+	It("should skip logged bodies that were not json when replaying", func() {
+		log.WriteString("\"not json\"\n{\"object\":\"page\"}\n")
+
+		calls := 0
+		err := ReplayEvents(log, func(cb *Callback) error {
+			calls++
+			return nil
+		})
+
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should stop replaying at the first handler error", func() {
+		log.WriteString("{\"object\":\"page\"}\n{\"object\":\"page\"}\n")
+		handlerErr := errors.New("handler failed")
+
+		calls := 0
+		err := ReplayEvents(log, func(cb *Callback) error {
+			calls++
+			return handlerErr
+		})
+
+		Expect(err).To(Equal(handlerErr))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should return an error for a line that is not a valid callback", func() {
+		err := ReplayEvents(strings.NewReader("not json\n"), func(cb *Callback) error {
+			return nil
+		})
+
+		Expect(err).ToNot(BeNil())
+	})
+})
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
@@ -0,0 +1,17 @@
+//go:build go1.21
+// +build go1.21
+
+package fbmessenger
+
+import (
+	"golang.org/x/net/context"
+	"log/slog"
+)
+
+// SlogLogger adapts a *slog.Logger to a Logger for WithLogger. It is only available when
+// building with Go 1.21 or later, which added log/slog.
+func SlogLogger(logger *slog.Logger) Logger {
+	return LoggerFunc(func(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+		logger.Log(ctx, slog.Level(level), msg, keyvals...)
+	})
+}
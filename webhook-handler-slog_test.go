@@ -0,0 +1,31 @@
+//go:build go1.21
+// +build go1.21
+
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+)
+
+var _ = Describe("SlogLogger", func() {
+	It("should log rejected webhook requests to a slog logger", func() {
+		logs := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		handler := NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil, WithLogger(SlogLogger(logger)))
+
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"object":"page"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hub-Signature-256", "sha256=SIGNATURE")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		Expect(logs.String()).To(ContainSubstring(`"level":"ERROR","msg":"rejected webhook request","component":"fbmessenger","event":"webhook_error"`))
+	})
+})
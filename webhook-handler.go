@@ -0,0 +1,476 @@
+package fbmessenger
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"golang.org/x/net/context"
+	"hash"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+/*
+TokenVerifier checks the verify token Facebook sends when a webhook is subscribed. Use
+StaticTokenVerifier for a single token, MapTokenVerifier for a set of tokens, such as one
+per page, or FuncTokenVerifier to look tokens up elsewhere, such as in a database.
+*/
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) bool
+}
+
+// StaticTokenVerifier is a TokenVerifier that accepts a single token.
+type StaticTokenVerifier string
+
+// Verify reports whether the token is the static token.
+func (v StaticTokenVerifier) Verify(ctx context.Context, token string) bool {
+	return token == string(v)
+}
+
+// MapTokenVerifier is a TokenVerifier that accepts the tokens mapped to true.
+type MapTokenVerifier map[string]bool
+
+// Verify reports whether the token is in the map and mapped to true.
+func (v MapTokenVerifier) Verify(ctx context.Context, token string) bool {
+	return v[token]
+}
+
+// FuncTokenVerifier is an adapter to allow the use of an ordinary function as a TokenVerifier.
+type FuncTokenVerifier func(ctx context.Context, token string) bool
+
+// Verify calls f(ctx, token).
+func (f FuncTokenVerifier) Verify(ctx context.Context, token string) bool {
+	return f(ctx, token)
+}
+
+/*
+WebhookHandler is an http.Handler for your webhook endpoint. It answers the verification
+challenge Facebook sends with a GET request when the webhook is subscribed, and parses the
+callbacks Facebook POSTs, passing each one to a handler function.
+
+	dispatcher := &fbmessenger.CallbackDispatcher{MessageHandler: MessageReceived}
+	http.Handle("/webhook", fbmessenger.NewWebhookHandler(fbmessenger.StaticTokenVerifier("YOUR_VERIFY_TOKEN"), dispatcher.Dispatch))
+*/
+type WebhookHandler struct {
+	verifier     TokenVerifier
+	handler      func(*Callback) error
+	parseOptions []ParseOption
+	appSecret    string
+	logger       Logger
+	logLevel     LogLevel
+
+	mu       sync.Mutex
+	stopped  bool
+	inFlight sync.WaitGroup
+}
+
+// HandlerOption is implemented by options that configure a WebhookHandler. Each ParseOption
+// is also a HandlerOption, which the handler uses when parsing callbacks.
+type HandlerOption interface {
+	applyToHandler(h *WebhookHandler)
+}
+
+type handlerOptionFunc func(h *WebhookHandler)
+
+func (o handlerOptionFunc) applyToHandler(h *WebhookHandler) {
+	o(h)
+}
+
+// WithAppSecret makes the handler check the signature of each callback with the app secret,
+// as ParseCallbackWithVerification does. Callbacks with a signature that does not match get
+// a 403 response.
+func WithAppSecret(appSecret string) HandlerOption {
+	return handlerOptionFunc(func(h *WebhookHandler) {
+		h.appSecret = appSecret
+	})
+}
+
+// LogLevel is the level of an error logged by a WebhookHandler. The values are those of the
+// matching log/slog levels.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = -4
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 8
+)
+
+/*
+Logger is implemented by the loggers a WebhookHandler logs rejected callbacks to. The keyvals
+alternate between string keys and their values. SlogLogger adapts a *slog.Logger, and
+LoggerFunc an ordinary function.
+*/
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// LoggerFunc is an adapter to allow the use of an ordinary function as a Logger.
+type LoggerFunc func(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+
+// Log calls f(ctx, level, msg, keyvals...).
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	f(ctx, level, msg, keyvals...)
+}
+
+/*
+WithLogger makes the handler log the callbacks it rejects, because they could not be parsed,
+failed validation or were not signed with the app secret. Requests that do not look like they
+were sent by Facebook, with no signature or a body that is not JSON, are logged at the debug
+level, signature failures at the warn level and other errors at the error level.
+*/
+func WithLogger(logger Logger) HandlerOption {
+	return handlerOptionFunc(func(h *WebhookHandler) {
+		h.logger = logger
+	})
+}
+
+// WithLogLevel sets the lowest level of the errors logged by a handler with WithLogger, such
+// as LogLevelWarn to leave out requests that were not sent by Facebook. All are logged by
+// default.
+func WithLogLevel(level LogLevel) HandlerOption {
+	return handlerOptionFunc(func(h *WebhookHandler) {
+		h.logLevel = level
+	})
+}
+
+// NewWebhookHandler creates a WebhookHandler that checks verify tokens with verifier and
+// passes callbacks to handler. When handler is nil, callbacks are parsed and answered with a
+// 200 but not passed on.
+func NewWebhookHandler(verifier TokenVerifier, handler func(*Callback) error, options ...HandlerOption) *WebhookHandler {
+	h := &WebhookHandler{
+		verifier: verifier,
+		handler:  handler,
+		logLevel: LogLevelDebug,
+	}
+
+	for _, option := range options {
+		option.applyToHandler(h)
+	}
+
+	return h
+}
+
+// ServeHTTP answers GET requests as verification challenges and POST requests as callbacks.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.serveChallenge(w, r)
+	case "POST":
+		h.serveCallback(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) serveChallenge(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("hub.mode") != "subscribe" || !h.verifier.Verify(r.Context(), query.Get("hub.verify_token")) {
+		http.Error(w, "invalid verify token", http.StatusForbidden)
+		return
+	}
+
+	w.Write([]byte(query.Get("hub.challenge")))
+}
+
+func (h *WebhookHandler) serveCallback(w http.ResponseWriter, r *http.Request) {
+	if !h.startCallback() {
+		http.Error(w, "webhook is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.inFlight.Done()
+
+	var cb *Callback
+	var err error
+	if h.appSecret != "" {
+		cb, err = ParseCallbackWithVerification(r, h.appSecret, h.parseOptions...)
+	} else {
+		cb, err = ParseCallback(r, h.parseOptions...)
+	}
+
+	if err != nil {
+		h.logError(r, err)
+	}
+
+	if err == ErrBodyTooLarge {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	} else if err == ErrInvalidSignature {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.handler == nil {
+		return
+	}
+
+	if err := h.handler(cb); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *WebhookHandler) logError(r *http.Request, err error) {
+	if h.logger == nil {
+		return
+	}
+
+	level := LogLevelError
+	if err == ErrInvalidSignature {
+		level = LogLevelWarn
+	} else if looksLikeProbe(r) {
+		level = LogLevelDebug
+	}
+
+	if level < h.logLevel {
+		return
+	}
+
+	h.logger.Log(r.Context(), level, "rejected webhook request",
+		"component", "fbmessenger",
+		"event", "webhook_error",
+		"error", err.Error(),
+		"remote_addr", r.RemoteAddr)
+}
+
+// looksLikeProbe reports whether a request was probably not sent by Facebook, which signs
+// every callback and always sends JSON.
+func looksLikeProbe(r *http.Request) bool {
+	if r.Header.Get("X-Hub-Signature-256") == "" && r.Header.Get("X-Hub-Signature") == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err != nil || mediaType != "application/json"
+}
+
+// startCallback records a callback as in flight, unless the handler has been stopped.
+func (h *WebhookHandler) startCallback() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return false
+	}
+
+	h.inFlight.Add(1)
+	return true
+}
+
+// stop makes the handler respond to new callbacks with a 503, so that Facebook retries them
+// later, and waits for the callbacks in flight to be handled or ctx to be done.
+func (h *WebhookHandler) stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.stopped = true
+	h.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+ServeChallenge returns a handler for just the verification challenge Facebook sends with a
+GET request when the webhook is subscribed, for routers that route GET and POST requests
+to different handlers. Use it along with ServeMessages.
+
+	r.Get("/webhook", fbmessenger.ServeChallenge("YOUR_VERIFY_TOKEN"))
+	r.Post("/webhook", fbmessenger.ServeMessages("YOUR_APP_SECRET", handleCallback))
+*/
+func ServeChallenge(verifyToken string) http.HandlerFunc {
+	h := NewWebhookHandler(StaticTokenVerifier(verifyToken), nil)
+
+	return h.serveChallenge
+}
+
+/*
+ServeMessages returns a handler for just the callbacks Facebook POSTs, which checks the
+signature of each request with the app secret before passing the callback to cb. Use it
+along with ServeChallenge. Requests with a signature that does not match get a 403 response,
+and requests with no signature, which were not sent by Facebook, get a 400 response.
+*/
+func ServeMessages(appSecret string, cb func(*Callback)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		callback, err := ParseCallbackWithVerification(r, appSecret)
+		if err == ErrInvalidSignature {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cb(callback)
+	}
+}
+
+// DefaultMaxBodySize is the largest body, in bytes, read by ParseCallback unless
+// WithMaxBodySize is used.
+const DefaultMaxBodySize int64 = 1 << 20
+
+// ErrBodyTooLarge is returned by ParseCallback when the body of the request is larger than
+// the maximum body size.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+type parseConfig struct {
+	maxBodySize int64
+}
+
+// ParseOption functions set optional configuration of ParseCallback. They can also be
+// passed to NewWebhookHandler.
+type ParseOption func(c *parseConfig)
+
+func (o ParseOption) applyToHandler(h *WebhookHandler) {
+	h.parseOptions = append(h.parseOptions, o)
+}
+
+// WithMaxBodySize sets the largest body, in bytes, that is read, in place of DefaultMaxBodySize.
+// A size of zero or less keeps DefaultMaxBodySize.
+func WithMaxBodySize(bytes int64) ParseOption {
+	return func(c *parseConfig) {
+		if bytes <= 0 {
+			bytes = DefaultMaxBodySize
+		}
+
+		c.maxBodySize = bytes
+	}
+}
+
+/*
+ParseCallback decodes the body of a webhook request into a Callback. An error is returned
+if the body is not valid JSON or the callback fails Callback.Validate. At most
+DefaultMaxBodySize bytes are read, or the size set with WithMaxBodySize, and ErrBodyTooLarge
+is returned for larger bodies.
+*/
+func ParseCallback(r *http.Request, options ...ParseOption) (*Callback, error) {
+	body, err := readBody(r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCallbackBody(body)
+}
+
+// ParseCallbackWithVerification is like ParseCallback but first checks the signature of
+// the request with VerifySignature, returning ErrMissingSignatureHeader or ErrInvalidSignature
+// when the check fails.
+func ParseCallbackWithVerification(r *http.Request, appSecret string, options ...ParseOption) (*Callback, error) {
+	body, err := readBody(r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifySignature(r, body, appSecret); err != nil {
+		return nil, err
+	}
+
+	return parseCallbackBody(body)
+}
+
+/*
+ReadBody reads the body of a webhook request as ParseCallback does, for packages that decode
+other kinds of callbacks. At most DefaultMaxBodySize bytes are read, or the size set with
+WithMaxBodySize, and ErrBodyTooLarge is returned for larger bodies.
+*/
+func ReadBody(r *http.Request, options ...ParseOption) ([]byte, error) {
+	return readBody(r, options)
+}
+
+func readBody(r *http.Request, options []ParseOption) ([]byte, error) {
+	config := &parseConfig{maxBodySize: DefaultMaxBodySize}
+	for _, option := range options {
+		option(config)
+	}
+
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, config.maxBodySize))
+	if err != nil && int64(len(body)) >= config.maxBodySize {
+		return nil, ErrBodyTooLarge
+	} else if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func parseCallbackBody(body []byte) (*Callback, error) {
+	cb := &Callback{}
+	if err := json.Unmarshal(body, cb); err != nil {
+		return nil, err
+	}
+
+	if err := cb.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cb, nil
+}
+
+// The errors returned by VerifySignature. ErrMissingSignatureHeader indicates a request
+// that was not sent by Facebook at all, and ErrInvalidSignature one that was not signed with
+// the app secret.
+var (
+	ErrMissingSignatureHeader = errors.New("request has no X-Hub-Signature-256 or X-Hub-Signature header")
+	ErrInvalidSignature       = errors.New("request signature does not match the body")
+)
+
+/*
+VerifySignature checks that the body of a webhook request was signed by Facebook with the
+app secret. The X-Hub-Signature-256 header is checked when it is present, and otherwise the
+older X-Hub-Signature header. ErrMissingSignatureHeader is returned when neither header is
+present, and ErrInvalidSignature when the signature does not match.
+
+See https://developers.facebook.com/docs/messenger-platform/webhooks#validate-payloads
+*/
+func VerifySignature(r *http.Request, body []byte, appSecret string) error {
+	signature, newHash := r.Header.Get("X-Hub-Signature-256"), sha256.New
+	if signature == "" {
+		signature, newHash = r.Header.Get("X-Hub-Signature"), sha1.New
+	}
+
+	if signature == "" {
+		return ErrMissingSignatureHeader
+	}
+
+	if !validSignature(signature, body, appSecret, newHash) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func validSignature(signature string, body []byte, appSecret string, newHash func() hash.Hash) bool {
+	parts := strings.SplitN(signature, "=", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(appSecret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
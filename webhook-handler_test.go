@@ -0,0 +1,338 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang.org/x/net/context"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+var _ = Describe("WebhookHandler", func() {
+	var (
+		received []*Callback
+		handler  *WebhookHandler
+	)
+
+	BeforeEach(func() {
+		received = nil
+
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), func(cb *Callback) error {
+			received = append(received, cb)
+			return nil
+		})
+	})
+
+	challenge := func(token string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/webhook?hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token="+token, nil))
+
+		return recorder
+	}
+
+	It("should answer the challenge when the verify token is accepted", func() {
+		recorder := challenge("VERIFY_TOKEN")
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("1158201444"))
+	})
+
+	It("should reject the challenge when the verify token is not accepted", func() {
+		recorder := challenge("WRONG_TOKEN")
+
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		Expect(recorder.Body.String()).NotTo(ContainSubstring("1158201444"))
+	})
+
+	It("should check verify tokens against a map of tokens", func() {
+		handler = NewWebhookHandler(MapTokenVerifier{"PAGE_1_TOKEN": true, "PAGE_2_TOKEN": true}, nil)
+
+		Expect(challenge("PAGE_2_TOKEN").Code).To(Equal(http.StatusOK))
+		Expect(challenge("PAGE_3_TOKEN").Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should check verify tokens with a function", func() {
+		var checked []string
+		handler = NewWebhookHandler(FuncTokenVerifier(func(ctx context.Context, token string) bool {
+			checked = append(checked, token)
+			return strings.HasPrefix(token, "PAGE_")
+		}), nil)
+
+		Expect(challenge("PAGE_1_TOKEN").Code).To(Equal(http.StatusOK))
+		Expect(challenge("OTHER_TOKEN").Code).To(Equal(http.StatusForbidden))
+		Expect(checked).To(Equal([]string{"PAGE_1_TOKEN", "OTHER_TOKEN"}))
+	})
+
+	It("should pass callbacks to the handler", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Entries[0].Messaging[0].Message.Text).To(Equal("hello, world!"))
+	})
+
+	It("should pass checkbox plugin opt-ins, which have no sender, to the handler", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("checkbox-plugin-optin.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Entries[0].Messaging[0].OptIn.UserRef).To(Equal("UNIQUE_REF_PARAM"))
+	})
+
+	It("should answer callbacks without passing them on when there is no handler", func() {
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject callbacks that are not valid", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"object":"page"}`)))
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(received).To(BeEmpty())
+	})
+
+	It("should return ErrBodyTooLarge for a body over the default limit", func() {
+		body := `{"object":"page","padding":"` + strings.Repeat("a", 2<<20) + `"}`
+
+		_, err := ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+
+		Expect(err).To(Equal(ErrBodyTooLarge))
+	})
+
+	It("should read a body up to the limit set with WithMaxBodySize", func() {
+		body := loadCallbackString("text-message.json")
+
+		_, err := ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(body)), WithMaxBodySize(int64(len(body))))
+		Expect(err).To(BeNil())
+
+		_, err = ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(body)), WithMaxBodySize(int64(len(body)-1)))
+		Expect(err).To(Equal(ErrBodyTooLarge))
+	})
+
+	It("should keep the default limit when WithMaxBodySize is given zero or less", func() {
+		for _, size := range []int64{0, -1} {
+			_, err := ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))), WithMaxBodySize(size))
+			Expect(err).To(BeNil())
+
+			body := `{"object":"page","padding":"` + strings.Repeat("a", 2<<20) + `"}`
+			_, err = ParseCallback(httptest.NewRequest("POST", "/webhook", strings.NewReader(body)), WithMaxBodySize(size))
+			Expect(err).To(Equal(ErrBodyTooLarge))
+		}
+	})
+
+	It("should reject callbacks over the limit set for the handler", func() {
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), func(cb *Callback) error {
+			received = append(received, cb)
+			return nil
+		}, WithMaxBodySize(100))
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(received).To(BeEmpty())
+	})
+
+	Describe("Logging", func() {
+		type logEntry struct {
+			level   LogLevel
+			msg     string
+			keyvals []interface{}
+		}
+
+		var logged []logEntry
+
+		BeforeEach(func() {
+			logged = nil
+		})
+
+		logger := LoggerFunc(func(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+			logged = append(logged, logEntry{level, msg, keyvals})
+		})
+
+		post := func(body, signature string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if signature != "" {
+				req.Header.Set("X-Hub-Signature-256", signature)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			return recorder
+		}
+
+		It("should log a request with an invalid signature as a warning", func() {
+			handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil, WithAppSecret("APP_SECRET"), WithLogger(logger))
+			body := loadCallbackString("text-message.json")
+
+			recorder := post(body, "sha256="+sign(sha256.New, "OTHER_SECRET", body))
+
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(logged).To(Equal([]logEntry{{LogLevelWarn, "rejected webhook request", []interface{}{
+				"component", "fbmessenger",
+				"event", "webhook_error",
+				"error", "request signature does not match the body",
+				"remote_addr", "192.0.2.1:1234",
+			}}}))
+		})
+
+		It("should log an invalid callback from facebook as an error", func() {
+			handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil, WithLogger(logger))
+
+			post(`{"object":"page"}`, "sha256=SIGNATURE")
+
+			Expect(logged).To(HaveLen(1))
+			Expect(logged[0].level).To(Equal(LogLevelError))
+		})
+
+		It("should log a request that was not sent by facebook at the debug level", func() {
+			handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil, WithLogger(logger))
+
+			post(`not json`, "")
+
+			Expect(logged).To(HaveLen(1))
+			Expect(logged[0].level).To(Equal(LogLevelDebug))
+		})
+
+		It("should not log errors below the log level", func() {
+			handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), nil, WithLogger(logger), WithLogLevel(LogLevelWarn))
+
+			post(`not json`, "")
+
+			Expect(logged).To(BeEmpty())
+		})
+	})
+
+	It("should dispatch checkbox plugin opt-ins to the authentication handler", func() {
+		var optIns []*OptIn
+		dispatcher := &CallbackDispatcher{
+			AuthenticationHandler: func(entry *MessagingEntry) error {
+				optIns = append(optIns, entry.OptIn)
+				return nil
+			},
+		}
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), dispatcher.Dispatch)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("checkbox-plugin-optin.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(optIns).To(HaveLen(1))
+		Expect(optIns[0].IsCheckboxPlugin()).To(BeTrue())
+	})
+
+	It("should return an error from the handler as a server error", func() {
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), func(cb *Callback) error {
+			return errors.New("handler failed")
+		})
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+	})
+})
+
+var _ = Describe("Standalone Webhook Handlers", func() {
+	const appSecret = "APP_SECRET"
+
+	var (
+		body     string
+		received []*Callback
+		serve    http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		body = loadCallbackString("text-message.json")
+		received = nil
+
+		serve = ServeMessages(appSecret, func(cb *Callback) {
+			received = append(received, cb)
+		})
+	})
+
+	post := func(header, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set(header, signature)
+		}
+
+		recorder := httptest.NewRecorder()
+		serve(recorder, req)
+
+		return recorder
+	}
+
+	It("should answer the challenge", func() {
+		recorder := httptest.NewRecorder()
+		ServeChallenge("VERIFY_TOKEN")(recorder, httptest.NewRequest("GET", "/webhook?hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=VERIFY_TOKEN", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("1158201444"))
+	})
+
+	It("should pass callbacks signed with sha256 to the handler", func() {
+		recorder := post("X-Hub-Signature-256", "sha256="+sign(sha256.New, appSecret, body))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(received).To(HaveLen(1))
+	})
+
+	It("should pass callbacks signed with sha1 to the handler", func() {
+		recorder := post("X-Hub-Signature", "sha1="+sign(sha1.New, appSecret, body))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(received).To(HaveLen(1))
+	})
+
+	It("should reject callbacks signed with a different secret", func() {
+		recorder := post("X-Hub-Signature-256", "sha256="+sign(sha256.New, "OTHER_SECRET", body))
+
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		Expect(received).To(BeEmpty())
+	})
+
+	It("should reject callbacks that are not signed", func() {
+		recorder := post("", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(received).To(BeEmpty())
+	})
+
+	It("should distinguish missing signatures from invalid ones", func() {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		_, err := ParseCallbackWithVerification(req, appSecret)
+		Expect(err).To(Equal(ErrMissingSignatureHeader))
+
+		req = httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", "sha256=not-hex")
+		_, err = ParseCallbackWithVerification(req, appSecret)
+		Expect(err).To(Equal(ErrInvalidSignature))
+	})
+})
+
+func sign(newHash func() hash.Hash, secret, body string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(body))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,129 @@
+package fbmessenger
+
+import (
+	"crypto/tls"
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+)
+
+/*
+WebhookServer serves a WebhookHandler over HTTP, or HTTPS, which Facebook requires for
+production webhooks, and shuts down gracefully, so that callbacks being handled are not lost
+when the server is stopped.
+
+	server := fbmessenger.NewWebhookServer(":443", handler, fbmessenger.WithTLSCertFile("cert.pem", "key.pem"))
+	go server.Start(ctx)
+	...
+	err := server.Shutdown(ctx)
+*/
+type WebhookServer struct {
+	server   *http.Server
+	handler  *WebhookHandler
+	certFile string
+	keyFile  string
+}
+
+// ServerOption functions set optional configuration of a WebhookServer.
+type ServerOption func(s *WebhookServer)
+
+// NewWebhookServer creates a WebhookServer that serves handler at addr, such as ":8080".
+func NewWebhookServer(addr string, handler *WebhookHandler, options ...ServerOption) *WebhookServer {
+	s := &WebhookServer{
+		server:  &http.Server{Addr: addr, Handler: handler},
+		handler: handler,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// WithTLSConfig serves HTTPS with the given configuration, which must hold the certificates
+// unless they are set with WithTLSCertFile.
+func WithTLSConfig(config *tls.Config) ServerOption {
+	return func(s *WebhookServer) {
+		s.server.TLSConfig = config
+	}
+}
+
+// WithTLSCertFile serves HTTPS with the certificate and matching private key in the given
+// PEM files.
+func WithTLSCertFile(certFile, keyFile string) ServerOption {
+	return func(s *WebhookServer) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+/*
+Start runs the server until it is shut down, either by Shutdown or by ctx being done, and
+returns the error that stopped it. Nil is returned when the server was shut down.
+*/
+func (s *WebhookServer) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr())
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+/*
+Serve is like Start, but accepts connections on listener instead of listening on the address
+the server was created with, which is closed when the server is shut down.
+*/
+func (s *WebhookServer) Serve(ctx context.Context, listener net.Listener) error {
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Shutdown(context.Background())
+		case <-stopped:
+		}
+	}()
+
+	var err error
+	if s.server.TLSConfig != nil || s.certFile != "" {
+		err = s.server.ServeTLS(listener, s.certFile, s.keyFile)
+	} else {
+		err = s.server.Serve(listener)
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+func (s *WebhookServer) addr() string {
+	if s.server.Addr != "" {
+		return s.server.Addr
+	}
+
+	if s.server.TLSConfig != nil || s.certFile != "" {
+		return ":https"
+	}
+
+	return ":http"
+}
+
+/*
+Shutdown stops the handler accepting callbacks, which are answered with a 503 so Facebook
+sends them again later, waits for the callbacks being handled to finish, and then shuts down
+the HTTP server. If ctx is done first, the HTTP server is closed without waiting any longer
+and the error of ctx is returned.
+*/
+func (s *WebhookServer) Shutdown(ctx context.Context) error {
+	if err := s.handler.stop(ctx); err != nil {
+		s.server.Close()
+		return err
+	}
+
+	return s.server.Shutdown(ctx)
+}
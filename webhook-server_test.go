@@ -0,0 +1,106 @@
+package fbmessenger_test
+
+import (
+	. "github.com/ekyoung/fbmessenger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+)
+
+var _ = Describe("WebhookServer", func() {
+	var (
+		addr     string
+		listener net.Listener
+		handled  chan *Callback
+		release  chan struct{}
+		handler  *WebhookHandler
+		server   *WebhookServer
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		addr = listener.Addr().String()
+
+		handled = make(chan *Callback, 1)
+		release = make(chan struct{})
+
+		var calls int32
+		handledCh, releaseCh := handled, release
+		handler = NewWebhookHandler(StaticTokenVerifier("VERIFY_TOKEN"), func(cb *Callback) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				handledCh <- cb
+				<-releaseCh
+			}
+			return nil
+		})
+		server = NewWebhookServer(addr, handler)
+	})
+
+	post := func() (*http.Response, error) {
+		return http.Post("http://"+addr+"/webhook", "application/json", strings.NewReader(loadCallbackString("text-message.json")))
+	}
+
+	It("should finish handling callbacks in flight before shutting down", func() {
+		started := make(chan error, 1)
+		go func() { started <- server.Serve(context.Background(), listener) }()
+
+		responses := make(chan *http.Response, 1)
+		go func() {
+			resp, _ := post()
+			responses <- resp
+		}()
+		Eventually(handled).Should(Receive())
+
+		shutdown := make(chan error, 1)
+		go func() { shutdown <- server.Shutdown(context.Background()) }()
+
+		Eventually(func() int {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", strings.NewReader(loadCallbackString("text-message.json"))))
+			return recorder.Code
+		}).Should(Equal(http.StatusServiceUnavailable))
+		Consistently(shutdown).ShouldNot(Receive())
+
+		close(release)
+
+		var resp *http.Response
+		Eventually(responses).Should(Receive(&resp))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Eventually(shutdown).Should(Receive(BeNil()))
+		Eventually(started).Should(Receive(BeNil()))
+	})
+
+	It("should close the server and return an error when the context is done before callbacks finish", func() {
+		started := make(chan error, 1)
+		go func() { started <- server.Serve(context.Background(), listener) }()
+
+		go post()
+		Eventually(handled).Should(Receive())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Expect(server.Shutdown(ctx)).To(Equal(context.Canceled))
+		Eventually(started).Should(Receive(BeNil()))
+		close(release)
+	})
+
+	It("should stop when the context passed to Start is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		started := make(chan error, 1)
+		go func() { started <- server.Serve(ctx, listener) }()
+		cancel()
+
+		Eventually(started).Should(Receive(BeNil()))
+	})
+})
@@ -0,0 +1,53 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ekyoung/fbmessenger"
+)
+
+/*
+ParseCallback decodes the body of a webhook request into a Callback. An error is returned
+if the body is not valid JSON or the callback is not for a WhatsApp Business Account. The
+body is read with fbmessenger.ReadBody, so the same limit applies as to Messenger callbacks,
+and fbmessenger.WithMaxBodySize changes it.
+*/
+func ParseCallback(r *http.Request, options ...fbmessenger.ParseOption) (*Callback, error) {
+	body, err := fbmessenger.ReadBody(r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCallbackBody(body)
+}
+
+// ParseCallbackWithVerification is like ParseCallback but first checks the signature of
+// the request with fbmessenger.VerifySignature, returning fbmessenger.ErrMissingSignatureHeader
+// or fbmessenger.ErrInvalidSignature when the check fails.
+func ParseCallbackWithVerification(r *http.Request, appSecret string, options ...fbmessenger.ParseOption) (*Callback, error) {
+	body, err := fbmessenger.ReadBody(r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fbmessenger.VerifySignature(r, body, appSecret); err != nil {
+		return nil, err
+	}
+
+	return parseCallbackBody(body)
+}
+
+func parseCallbackBody(body []byte) (*Callback, error) {
+	cb := &Callback{}
+	if err := json.Unmarshal(body, cb); err != nil {
+		return nil, err
+	}
+
+	if cb.Platform() != fbmessenger.PlatformWhatsApp {
+		return nil, fmt.Errorf("object is %q, expected \"whatsapp_business_account\"", cb.Object)
+	}
+
+	return cb, nil
+}
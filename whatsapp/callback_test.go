@@ -0,0 +1,119 @@
+package whatsapp_test
+
+import (
+	"github.com/ekyoung/fbmessenger"
+	. "github.com/ekyoung/fbmessenger/whatsapp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Callbacks", func() {
+	It("should parse a callback with a text message", func() {
+		cb, err := ParseCallback(createRequest(loadCallbackString("text-message.json")))
+
+		Expect(err).To(BeNil())
+		Expect(cb.Entries[0].BusinessAccountId).To(Equal("102290129340398"))
+
+		change := cb.Entries[0].Changes[0]
+		Expect(change.Field).To(Equal("messages"))
+		Expect(change.Value.Metadata.PhoneNumberId).To(Equal("106540352242922"))
+		Expect(change.Value.Contacts[0].Profile.Name).To(Equal("Sheena Nelson"))
+
+		message := change.Value.Messages[0]
+		Expect(message.From).To(Equal("16505551234"))
+		Expect(message.Type).To(Equal("text"))
+		Expect(message.Text.Body).To(Equal("hello, world!"))
+		Expect(message.At()).To(Equal(time.Unix(1749416383, 0).UTC()))
+	})
+
+	It("should parse a callback with a message status", func() {
+		cb, err := ParseCallback(createRequest(loadCallbackString("status.json")))
+
+		Expect(err).To(BeNil())
+
+		status := cb.Entries[0].Changes[0].Value.Statuses[0]
+		Expect(status.Status).To(Equal("delivered"))
+		Expect(status.RecipientId).To(Equal("16505551234"))
+		Expect(status.At()).To(Equal(time.Unix(1750263773, 0).UTC()))
+	})
+
+	It("should reject a callback that is not for a WhatsApp Business Account", func() {
+		_, err := ParseCallback(createRequest(`{"object":"page","entry":[]}`))
+
+		Expect(err).To(MatchError(`object is "page", expected "whatsapp_business_account"`))
+	})
+
+	It("should reject a body that is not JSON", func() {
+		_, err := ParseCallback(createRequest("not json"))
+
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should reject a body over the limit", func() {
+		_, err := ParseCallback(createRequest(loadCallbackString("text-message.json")), fbmessenger.WithMaxBodySize(100))
+
+		Expect(err).To(Equal(fbmessenger.ErrBodyTooLarge))
+	})
+
+	Describe("Signature Verification", func() {
+		const appSecret = "APP_SECRET"
+
+		It("should parse a callback signed with the app secret", func() {
+			body := loadCallbackString("text-message.json")
+			req := createRequest(body)
+			req.Header.Set("X-Hub-Signature-256", "sha256="+sign(appSecret, body))
+
+			cb, err := ParseCallbackWithVerification(req, appSecret)
+
+			Expect(err).To(BeNil())
+			Expect(cb.Entries[0].BusinessAccountId).To(Equal("102290129340398"))
+		})
+
+		It("should reject a callback signed with a different secret", func() {
+			body := loadCallbackString("text-message.json")
+			req := createRequest(body)
+			req.Header.Set("X-Hub-Signature-256", "sha256="+sign("OTHER_SECRET", body))
+
+			_, err := ParseCallbackWithVerification(req, appSecret)
+
+			Expect(err).To(Equal(fbmessenger.ErrInvalidSignature))
+		})
+
+		It("should reject a callback that is not signed", func() {
+			_, err := ParseCallbackWithVerification(createRequest(loadCallbackString("text-message.json")), appSecret)
+
+			Expect(err).To(Equal(fbmessenger.ErrMissingSignatureHeader))
+		})
+	})
+})
+
+func createRequest(body string) *http.Request {
+	return httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+}
+
+func loadCallbackString(fileName string) string {
+	fileBytes, err := ioutil.ReadFile("./sample-callback-data/" + fileName)
+	if err != nil {
+		Fail(fmt.Sprintf("Error reading file \"%v\": %v", fileName, err))
+	}
+
+	return string(fileBytes)
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,11 @@
+/*
+Package whatsapp provides types for the webhook callbacks of the WhatsApp Business Platform.
+
+WhatsApp callbacks are delivered by Meta's Graph API like Messenger callbacks, but have a
+different structure: each entry holds a list of changes, and each change holds the messages
+and message statuses for one business phone number. These types are kept in their own
+package so that they do not clash with the Messenger types in the fbmessenger package.
+
+See https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/components
+*/
+package whatsapp
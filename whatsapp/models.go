@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ekyoung/fbmessenger"
+)
+
+// Callback is the top level structure that represents a callback received by your
+// webhook endpoint from the WhatsApp Business Platform.
+type Callback struct {
+	Object  string   `json:"object" binding:"required"`
+	Entries []*Entry `json:"entry" binding:"required"`
+}
+
+// Platform returns the platform the callback was received from, based on its Object.
+func (cb *Callback) Platform() fbmessenger.Platform {
+	return fbmessenger.PlatformFromObject(cb.Object)
+}
+
+// Entry holds the changes for a single WhatsApp Business Account.
+type Entry struct {
+	BusinessAccountId string    `json:"id" binding:"required"`
+	Changes           []*Change `json:"changes"`
+}
+
+// Change is a single notification for a business account. Field identifies the kind of
+// notification, which is "messages" for both incoming messages and message statuses.
+type Change struct {
+	Field string       `json:"field" binding:"required"`
+	Value *ChangeValue `json:"value"`
+}
+
+// ChangeValue holds the contents of a change. Either Messages or Statuses will be set.
+type ChangeValue struct {
+	MessagingProduct string     `json:"messaging_product"`
+	Metadata         *Metadata  `json:"metadata"`
+	Contacts         []*Contact `json:"contacts,omitempty"`
+	Messages         []*Message `json:"messages,omitempty"`
+	Statuses         []*Status  `json:"statuses,omitempty"`
+}
+
+// Metadata identifies the business phone number a change is for.
+type Metadata struct {
+	DisplayPhoneNumber string `json:"display_phone_number"`
+	PhoneNumberId      string `json:"phone_number_id"`
+}
+
+// Contact is the customer who sent the messages of a change.
+type Contact struct {
+	Profile Profile `json:"profile"`
+	WaId    string  `json:"wa_id"`
+}
+
+// Profile holds the name of a customer.
+type Profile struct {
+	Name string `json:"name"`
+}
+
+/*
+Message represents a message a customer has sent to your business phone number. Type
+identifies which of the content fields is set.
+
+See https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/components#messages-object
+*/
+type Message struct {
+	From      string `json:"from" binding:"required"`
+	Id        string `json:"id" binding:"required"`
+	Timestamp string `json:"timestamp" binding:"required"`
+	Type      string `json:"type" binding:"required"`
+	Text      *Text  `json:"text,omitempty"`
+}
+
+// At returns the Timestamp of the message as a time.Time. WhatsApp timestamps are in
+// seconds since the Unix epoch, unlike Messenger timestamps which are in milliseconds.
+func (m *Message) At() time.Time {
+	return parseTimestamp(m.Timestamp)
+}
+
+// Text is the content of a text message.
+type Text struct {
+	Body string `json:"body"`
+}
+
+// Status reports a change in the status of a message your business sent, such as it
+// being delivered or read.
+type Status struct {
+	Id          string `json:"id" binding:"required"`
+	Status      string `json:"status" binding:"required"`
+	Timestamp   string `json:"timestamp" binding:"required"`
+	RecipientId string `json:"recipient_id" binding:"required"`
+}
+
+// At returns the Timestamp of the status as a time.Time.
+func (s *Status) At() time.Time {
+	return parseTimestamp(s.Timestamp)
+}
+
+func parseTimestamp(seconds string) time.Time {
+	value, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || value == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(value, 0).UTC()
+}
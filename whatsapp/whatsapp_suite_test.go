@@ -0,0 +1,20 @@
+package whatsapp_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/ginkgo/reporters"
+	"testing"
+
+	"os"
+	"path/filepath"
+)
+
+func TestWhatsApp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	testReportsPath, _ := filepath.Abs("../test-reports")
+	os.MkdirAll(testReportsPath, 0777)
+	junitReporter := reporters.NewJUnitReporter(filepath.Join(testReportsPath, "whatsapp-junit.xml"))
+	RunSpecsWithDefaultAndCustomReporters(t, "WhatsApp Suite", []Reporter{junitReporter})
+}